@@ -0,0 +1,120 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RestarterPrefix starts the name of every ephemeral container the controller
+// adds to a pod to restart one of its containers in place
+const RestarterPrefix = "restart-"
+
+// ContainerRestartRequestSpec defines the desired state of ContainerRestartRequest
+// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="spec is immutable"
+type ContainerRestartRequestSpec struct {
+	// PodName names the MiniCloneSet pod, in the same namespace, whose
+	// containers are restarted
+	// +kubebuilder:validation:MinLength=1
+	PodName string `json:"podName"`
+
+	// Containers names the containers to restart. Each one is restarted in
+	// place, keeping the pod, its IP and its volumes; its main process must
+	// handle SIGTERM.
+	// +kubebuilder:validation:MinItems=1
+	// +listType=set
+	Containers []string `json:"containers"`
+}
+
+// ContainerRestartRequestPhase is the progress of a ContainerRestartRequest
+// +kubebuilder:validation:Enum=Pending;Restarting;Completed;Failed
+type ContainerRestartRequestPhase string
+
+const (
+	// RestartPending means the containers have not been asked to restart yet
+	RestartPending ContainerRestartRequestPhase = "Pending"
+	// RestartRestarting means the containers were asked to restart
+	RestartRestarting ContainerRestartRequestPhase = "Restarting"
+	// RestartCompleted means every container restarted and is ready again
+	RestartCompleted ContainerRestartRequestPhase = "Completed"
+	// RestartFailed means the request cannot be carried out
+	RestartFailed ContainerRestartRequestPhase = "Failed"
+)
+
+// ContainerRestartRequestStatus defines the observed state of ContainerRestartRequest
+type ContainerRestartRequestStatus struct {
+	// Phase is the progress of the request
+	// +optional
+	Phase ContainerRestartRequestPhase `json:"phase,omitempty"`
+
+	// Message explains the phase, e.g. why the request failed
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// ContainerStates reports the progress of every container
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	ContainerStates []ContainerRestartState `json:"containerStates,omitempty"`
+
+	// CompletionTime is when the request completed or failed
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// ContainerRestartState is the progress of restarting a single container
+type ContainerRestartState struct {
+	// Name is the name of the container
+	Name string `json:"name"`
+
+	// Restarted is true once the container restarted and is ready again
+	Restarted bool `json:"restarted"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+
+// ContainerRestartRequest is the Schema for the containerrestartrequests API.
+// It restarts containers of a MiniCloneSet pod without recreating the pod.
+type ContainerRestartRequest struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty,omitzero"`
+
+	// spec defines the desired state of ContainerRestartRequest
+	// +required
+	Spec ContainerRestartRequestSpec `json:"spec"`
+
+	// status defines the observed state of ContainerRestartRequest
+	// +optional
+	Status ContainerRestartRequestStatus `json:"status,omitempty,omitzero"`
+}
+
+// +kubebuilder:object:root=true
+
+// ContainerRestartRequestList contains a list of ContainerRestartRequest
+type ContainerRestartRequestList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ContainerRestartRequest `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ContainerRestartRequest{}, &ContainerRestartRequestList{})
+}
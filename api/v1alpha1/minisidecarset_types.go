@@ -0,0 +1,103 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SidecarUpdatingAnnotation marks a pod whose sidecar images were patched and
+// whose containers do not run the new images yet. It holds the name of the
+// MiniSidecarSet and is removed once the new sidecars are running and ready.
+const SidecarUpdatingAnnotation = "apps.example.com.my.domain/sidecar-updating"
+
+// MiniSidecarSetSpec defines the desired state of MiniSidecarSet
+type MiniSidecarSetSpec struct {
+	// Selector selects the MiniCloneSets, by their labels, whose pods get the
+	// sidecar containers
+	// +required
+	Selector *metav1.LabelSelector `json:"selector"`
+
+	// Containers are the sidecar containers merged into every new pod of the
+	// selected MiniCloneSets. Image changes are rolled out in place to
+	// existing pods; any other change only applies to new pods.
+	// +kubebuilder:validation:MinItems=1
+	Containers []corev1.Container `json:"containers"`
+
+	// MaxUnavailable is the maximum number of pods whose sidecars are being
+	// updated in place, either still restarting onto the new images or not
+	// ready yet
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=1
+	// +optional
+	MaxUnavailable int32 `json:"maxUnavailable,omitempty"`
+}
+
+// MiniSidecarSetStatus defines the observed state of MiniSidecarSet
+type MiniSidecarSetStatus struct {
+	// ObservedGeneration is the most recent generation observed by the controller
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// MatchedMiniCloneSets is the number of MiniCloneSets selected
+	MatchedMiniCloneSets int `json:"matchedMiniCloneSets"`
+
+	// MatchedPods is the number of pods of the selected MiniCloneSets
+	MatchedPods int `json:"matchedPods"`
+
+	// UpdatedPods is the number of matched pods running every sidecar container
+	// with its current image
+	UpdatedPods int `json:"updatedPods"`
+
+	// UpdatedReadyPods is the number of updated pods that are ready
+	UpdatedReadyPods int `json:"updatedReadyPods"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+
+// MiniSidecarSet is the Schema for the minisidecarsets API
+type MiniSidecarSet struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty,omitzero"`
+
+	// spec defines the desired state of MiniSidecarSet
+	// +required
+	Spec MiniSidecarSetSpec `json:"spec"`
+
+	// status defines the observed state of MiniSidecarSet
+	// +optional
+	Status MiniSidecarSetStatus `json:"status,omitempty,omitzero"`
+}
+
+// +kubebuilder:object:root=true
+
+// MiniSidecarSetList contains a list of MiniSidecarSet
+type MiniSidecarSetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MiniSidecarSet `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MiniSidecarSet{}, &MiniSidecarSetList{})
+}
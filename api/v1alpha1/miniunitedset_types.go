@@ -0,0 +1,153 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	// UnitedSetLabel names the MiniUnitedSet a MiniCloneSet was created for
+	UnitedSetLabel = "apps.example.com.my.domain/united-set"
+	// PoolLabel names the MiniUnitedSet pool a MiniCloneSet was created for
+	PoolLabel = "apps.example.com.my.domain/pool"
+	// AppliedTemplateAnnotation holds the template spec last applied to a
+	// pool's MiniCloneSet, so fields removed from the template can be removed
+	// from the MiniCloneSet without touching fields the template never set
+	AppliedTemplateAnnotation = "apps.example.com.my.domain/applied-template"
+)
+
+// MiniUnitedSetSpec defines the desired state of MiniUnitedSet
+type MiniUnitedSetSpec struct {
+	// Replicas is the total number of pods across all pools
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default=1
+	Replicas int `json:"replicas"`
+
+	// Template is used for the MiniCloneSet of every pool. Replicas and
+	// spread are set per pool.
+	Template MiniCloneSetTemplate `json:"template"`
+
+	// Pools are the node pools the pods are divided over
+	// +kubebuilder:validation:MinItems=1
+	// +listType=map
+	// +listMapKey=name
+	Pools []Pool `json:"pools"`
+}
+
+// MiniCloneSetTemplate describes the MiniCloneSets created for a MiniUnitedSet
+type MiniCloneSetTemplate struct {
+	// Labels are added to every MiniCloneSet
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Spec is the MiniCloneSet spec shared by all pools
+	Spec MiniCloneSetSpec `json:"spec"`
+}
+
+// Pool is a set of nodes that gets its own MiniCloneSet
+type Pool struct {
+	// Name is appended to the MiniUnitedSet name to name the pool's MiniCloneSet
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+
+	// NodeSelectorTerm selects the nodes of the pool
+	NodeSelectorTerm corev1.NodeSelectorTerm `json:"nodeSelectorTerm"`
+
+	// Tolerations are added to the pods of the pool, e.g. for spot nodes
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// Replicas is the share of the pods, as a number or a percentage of
+	// spec.replicas, that runs in this pool. Replicas left over are divided
+	// evenly across the pools that do not set it.
+	// +kubebuilder:validation:XIntOrString
+	// +optional
+	Replicas *intstr.IntOrString `json:"replicas,omitempty"`
+}
+
+// MiniUnitedSetStatus defines the observed state of MiniUnitedSet
+type MiniUnitedSetStatus struct {
+	// ObservedGeneration is the most recent generation observed by the controller
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// AvailableReplicas is the number of available pods across all pools
+	AvailableReplicas int `json:"availableReplicas"`
+
+	// UpdatedReadyReplicas is the number of ready pods running the template
+	// across all pools
+	UpdatedReadyReplicas int `json:"updatedReadyReplicas"`
+
+	// Pools reports the state of every pool
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Pools []PoolStatus `json:"pools,omitempty"`
+}
+
+// PoolStatus is the observed state of a pool
+type PoolStatus struct {
+	// Name is the name of the pool
+	Name string `json:"name"`
+
+	// Replicas is the number of pods assigned to the pool
+	Replicas int `json:"replicas"`
+
+	// AvailableReplicas is the number of available pods in the pool
+	AvailableReplicas int `json:"availableReplicas"`
+
+	// UpdatedReadyReplicas is the number of ready pods in the pool running the template
+	UpdatedReadyReplicas int `json:"updatedReadyReplicas"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+
+// MiniUnitedSet is the Schema for the miniunitedsets API
+type MiniUnitedSet struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty,omitzero"`
+
+	// spec defines the desired state of MiniUnitedSet
+	// +required
+	Spec MiniUnitedSetSpec `json:"spec"`
+
+	// status defines the observed state of MiniUnitedSet
+	// +optional
+	Status MiniUnitedSetStatus `json:"status,omitempty,omitzero"`
+}
+
+// +kubebuilder:object:root=true
+
+// MiniUnitedSetList contains a list of MiniUnitedSet
+type MiniUnitedSetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MiniUnitedSet `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MiniUnitedSet{}, &MiniUnitedSetList{})
+}
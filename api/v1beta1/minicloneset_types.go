@@ -17,14 +17,39 @@ limitations under the License.
 package v1beta1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
 
+// MiniCloneSetMode defines how the number of pods is determined
+// +kubebuilder:validation:Enum=Replicas;PerNode
+type MiniCloneSetMode string
+
+const (
+	// ReplicasMode runs spec.replicas pods
+	ReplicasMode MiniCloneSetMode = "Replicas"
+	// PerNodeMode runs exactly one pod on every node matching spec.nodeSelector
+	PerNodeMode MiniCloneSetMode = "PerNode"
+)
+
+// PodManagementPolicyType defines how pods are created and deleted
+// +kubebuilder:validation:Enum=OrderedReady;Parallel
+type PodManagementPolicyType string
+
+const (
+	// OrderedReadyPodManagement creates and deletes pods one at a time, in ordinal order
+	OrderedReadyPodManagement PodManagementPolicyType = "OrderedReady"
+	// ParallelPodManagement creates and deletes pods all at once
+	ParallelPodManagement PodManagementPolicyType = "Parallel"
+)
+
 // UpdateStrategyType defines the type of update strategy
-// +kubebuilder:validation:Enum=RollingUpdate;Recreate
+// +kubebuilder:validation:Enum=RollingUpdate;Recreate;BlueGreen
 type UpdateStrategyType string
 
 const (
@@ -32,6 +57,38 @@ const (
 	RollingUpdateStrategyType UpdateStrategyType = "RollingUpdate"
 	// RecreateStrategyType indicates that all pods are deleted first, then new ones are created
 	RecreateStrategyType UpdateStrategyType = "Recreate"
+	// BlueGreenStrategyType indicates that a full set of new pods is brought up next to
+	// the old ones and traffic is switched over once all of them are available
+	BlueGreenStrategyType UpdateStrategyType = "BlueGreen"
+
+	// ActiveLabel marks the pods that should receive traffic during a BlueGreen update
+	ActiveLabel = "apps.example.com.my.domain/active"
+	// SubsetLabel records the spread subset a pod was placed in
+	SubsetLabel = "apps.example.com.my.domain/subset"
+	// MiniCloneSetLabel names the MiniCloneSet that created a pod. Only the
+	// controller sets it; the pod unavailable budget webhook selects on it.
+	MiniCloneSetLabel = "apps.example.com.my.domain/minicloneset"
+	// NodeLabel records the node a pod was created for in PerNode mode
+	NodeLabel = "apps.example.com.my.domain/node"
+	// InstanceIDLabel holds an ID unique to each pod, which changes when the pod is recreated
+	InstanceIDLabel = "apps.example.com.my.domain/instance-id"
+	// OrdinalLabel holds the index a pod is named after
+	OrdinalLabel = "apps.example.com.my.domain/ordinal"
+	// PrePullLabel names the MiniCloneSet an image puller pod pulls the image for
+	PrePullLabel = "apps.example.com.my.domain/pre-pull"
+	// DeletionProtectionLabel protects a MiniCloneSet from deletion: Always
+	// refuses every delete, Cascading refuses deletes while it has ready pods
+	DeletionProtectionLabel = "policy.deletion-protection"
+	// DeletionProtectionAlways refuses to delete the MiniCloneSet
+	DeletionProtectionAlways = "Always"
+	// DeletionProtectionCascading refuses to delete the MiniCloneSet while it has ready pods
+	DeletionProtectionCascading = "Cascading"
+	// LifecycleStateLabel records where a pod is in its lifecycle
+	LifecycleStateLabel = "apps.example.com.my.domain/lifecycle-state"
+	// PreparingDeleteState marks a pod that waits for its PreDelete hook before it is deleted
+	PreparingDeleteState = "PreparingDelete"
+	// TeardownFinalizer holds a deleted MiniCloneSet until its pods are gone
+	TeardownFinalizer = "apps.example.com.my.domain/teardown"
 )
 
 // UpdateStrategy defines the update strategy configuration
@@ -44,6 +101,43 @@ type UpdateStrategy struct {
 	// +kubebuilder:default="25%"
 	// +optional
 	MaxUnavailable *string `json:"maxUnavailable,omitempty"`
+
+	// AutoRollback reverts the template to the last fully available revision
+	// when a rollout fails. Unset disables automatic rollback.
+	// +optional
+	AutoRollback *AutoRollbackPolicy `json:"autoRollback,omitempty"`
+
+	// RequireApproval stops every rollout of a new revision until the
+	// approve-revision annotation is set to its hash
+	// +optional
+	RequireApproval bool `json:"requireApproval,omitempty"`
+
+	// MaxPodUpdatesPerMinute caps how many outdated pods a RollingUpdate
+	// replaces per minute, spread evenly over the minute. Unset means no cap.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxPodUpdatesPerMinute *int32 `json:"maxPodUpdatesPerMinute,omitempty"`
+
+	// AllowedWindows restricts when outdated pods may be updated. Outside of
+	// every window the MiniCloneSet still scales but leaves outdated pods alone.
+	// Unset allows updates at any time.
+	// +optional
+	AllowedWindows []UpdateWindow `json:"allowedWindows,omitempty"`
+
+	// Canary walks a RollingUpdate through an ordered list of steps instead
+	// of updating every pod at once
+	// +optional
+	Canary *CanaryStrategy `json:"canary,omitempty"`
+
+	// BlueGreen configures the BlueGreen update strategy
+	// +optional
+	BlueGreen *BlueGreenStrategy `json:"blueGreen,omitempty"`
+
+	// PrePull pulls the image of a new revision onto the nodes of the pods
+	// before a RollingUpdate replaces them, so replacements start quickly.
+	// Unset disables pre-pulling.
+	// +optional
+	PrePull *ImagePrePullPolicy `json:"prePull,omitempty"`
 }
 
 // Container defines container configuration
@@ -51,9 +145,21 @@ type Container struct {
 	// Image specifies the container image to use
 	// +kubebuilder:validation:MinLength=1
 	Image string `json:"image"`
+
+	// Resources are the compute resources of the container. Changing only
+	// resources resizes running pods in place where the cluster supports it.
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// Env lists extra environment variables of the main container. Variables
+	// taken from pod labels or annotations through the downward API follow
+	// podMetadata changes by restarting the container in place.
+	// +optional
+	Env []corev1.EnvVar `json:"env,omitempty"`
 }
 
 // MiniCloneSetSpec defines the desired state of MiniCloneSet
+// +kubebuilder:validation:XValidation:rule="!(has(self.stableIdentity) && self.stableIdentity && has(self.updateStrategy) && has(self.updateStrategy.type) && self.updateStrategy.type == 'BlueGreen')",message="stableIdentity cannot be used with the BlueGreen update strategy"
 type MiniCloneSetSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
@@ -71,6 +177,87 @@ type MiniCloneSetSpec struct {
 	// UpdateStrategy specifies the strategy to use when updating pods
 	// +optional
 	UpdateStrategy UpdateStrategy `json:"updateStrategy,omitempty"`
+
+	// ProgressDeadlineSeconds is the maximum time in seconds for a rollout to
+	// make progress before it is considered failed. Unset means no deadline.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ProgressDeadlineSeconds *int32 `json:"progressDeadlineSeconds,omitempty"`
+
+	// ScaleSchedule changes the replica count whenever one of its entries
+	// fires. A manual edit of replicas takes priority until the next entry fires.
+	// +optional
+	ScaleSchedule []ScheduledScale `json:"scaleSchedule,omitempty"`
+
+	// Hibernate deletes every pod while remembering the replica count and
+	// revision they ran. Setting it back to false restores exactly that state.
+	// +optional
+	Hibernate bool `json:"hibernate,omitempty"`
+
+	// Spread places pods across subsets of nodes, such as zones or node pools
+	// +optional
+	Spread *SpreadPolicy `json:"spread,omitempty"`
+
+	// Mode is Replicas to run spec.replicas pods, or PerNode to run one pod on
+	// every node matching nodeSelector. Replicas, scaleSchedule and spread are
+	// ignored in PerNode mode.
+	// +kubebuilder:default=Replicas
+	// +optional
+	Mode MiniCloneSetMode `json:"mode,omitempty"`
+
+	// NodeSelector selects the nodes that run a pod in PerNode mode. An empty
+	// selector selects every node.
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// PodManagementPolicy is OrderedReady to create and delete pods one at a
+	// time, each waiting for the previous pods to be ready, or Parallel to
+	// create and delete them all at once
+	// +kubebuilder:default=Parallel
+	// +optional
+	PodManagementPolicy PodManagementPolicyType `json:"podManagementPolicy,omitempty"`
+
+	// StableIdentity keeps pods at ordinals 0..replicas-1. Scale down removes
+	// the highest ordinals and updates replace pods in place from the highest
+	// ordinal down, instead of surging a new pod next to the old one.
+	// +optional
+	StableIdentity bool `json:"stableIdentity,omitempty"`
+
+	// InstanceOverrides patch the pods of selected instances, e.g. to raise
+	// the log level or memory limit of a single pod. Overrides are part of the
+	// template, so changing them rolls out a new revision.
+	// +listType=map
+	// +listMapKey=selector
+	// +optional
+	InstanceOverrides []InstanceOverride `json:"instanceOverrides,omitempty"`
+
+	// PodMetadata holds labels and annotations added to every pod. Changing
+	// only pod metadata updates running pods in place instead of recreating them.
+	// +optional
+	PodMetadata *PodMetadata `json:"podMetadata,omitempty"`
+
+	// Service makes the controller create and own a Service named after the
+	// MiniCloneSet that selects its pods. Unset removes that Service. A
+	// Service of that name the MiniCloneSet does not own is left alone.
+	// +optional
+	Service *ServiceSpec `json:"service,omitempty"`
+
+	// DisruptionBudget makes the controller create and own a
+	// PodDisruptionBudget named after the MiniCloneSet that limits voluntary
+	// disruptions of its pods. Unset removes that PodDisruptionBudget.
+	// +optional
+	DisruptionBudget *DisruptionBudget `json:"disruptionBudget,omitempty"`
+
+	// PreDelete holds pods back from deletion until other controllers have
+	// removed the hook labels from them, for example after draining traffic.
+	// It is honored when the MiniCloneSet is deleted.
+	// +optional
+	PreDelete *PreDeleteHook `json:"preDelete,omitempty"`
+
+	// Teardown configures how the pods are deleted when the MiniCloneSet is
+	// deleted
+	// +optional
+	Teardown *TeardownStrategy `json:"teardown,omitempty"`
 }
 
 // MiniCloneSetStatus defines the observed state of MiniCloneSet.
@@ -80,6 +267,496 @@ type MiniCloneSetStatus struct {
 
 	// AvailableReplicas indicates the number of available replicas
 	AvailableReplicas int `json:"availableReplicas"`
+
+	// DesiredReplicas is the replica count the controller is working towards,
+	// taking the scale schedule into account
+	// +optional
+	DesiredReplicas int `json:"desiredReplicas,omitempty"`
+
+	// ActiveScaleSchedule is the scale schedule entry that fired most recently
+	// +optional
+	ActiveScaleSchedule *ScaleScheduleStatus `json:"activeScaleSchedule,omitempty"`
+
+	// Hibernation holds the state to restore while the MiniCloneSet is
+	// hibernating or waking up
+	// +optional
+	Hibernation *HibernationStatus `json:"hibernation,omitempty"`
+
+	// ImagePrePull is the progress of pulling the image of the update revision
+	// +optional
+	ImagePrePull *ImagePrePullStatus `json:"imagePrePull,omitempty"`
+
+	// ObservedGeneration is the most recent generation observed by the controller
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// UpdatedReplicas is the number of pods created from the update revision
+	// +optional
+	UpdatedReplicas int `json:"updatedReplicas,omitempty"`
+
+	// UpdatedReadyReplicas is the number of ready pods created from the update revision
+	// +optional
+	UpdatedReadyReplicas int `json:"updatedReadyReplicas,omitempty"`
+
+	// CurrentRevision is the last revision that every replica ran and was ready on
+	// +optional
+	CurrentRevision string `json:"currentRevision,omitempty"`
+
+	// UpdateRevision is the revision computed from the current spec
+	// +optional
+	UpdateRevision string `json:"updateRevision,omitempty"`
+
+	// CollisionCount is bumped when the hashes of two templates collide and
+	// is mixed into the hash of new revisions to tell them apart
+	// +optional
+	CollisionCount *int32 `json:"collisionCount,omitempty"`
+
+	// ActiveRevision is the revision whose pods carry the active label
+	// +optional
+	ActiveRevision string `json:"activeRevision,omitempty"`
+
+	// ActiveSwitchTime is when the active label last moved to another revision
+	// +optional
+	ActiveSwitchTime *metav1.Time `json:"activeSwitchTime,omitempty"`
+
+	// NextWindowStartTime is when the next allowed update window opens, set
+	// while every window is closed
+	// +optional
+	NextWindowStartTime *metav1.Time `json:"nextWindowStartTime,omitempty"`
+
+	// NextPodUpdateTime is when the pod update rate limit allows the next pod
+	// update, set while the rollout waits for it
+	// +optional
+	NextPodUpdateTime *metav1.Time `json:"nextPodUpdateTime,omitempty"`
+
+	// LastApproval records who approved the most recent gated rollout and when
+	// +optional
+	LastApproval *ApprovalStatus `json:"lastApproval,omitempty"`
+
+	// LastRollback records the most recent automatic rollback
+	// +optional
+	LastRollback *RollbackStatus `json:"lastRollback,omitempty"`
+
+	// CurrentStepIndex is the index of the canary step being executed
+	// +optional
+	CurrentStepIndex *int32 `json:"currentStepIndex,omitempty"`
+
+	// CurrentStepState is the state of the canary step being executed
+	// +optional
+	CurrentStepState CanaryStepState `json:"currentStepState,omitempty"`
+
+	// CurrentStepPausedAt is when the canary step being executed started pausing
+	// +optional
+	CurrentStepPausedAt *metav1.Time `json:"currentStepPausedAt,omitempty"`
+
+	// LastProgressTime is the last time the rollout created or readied an updated pod
+	// +optional
+	LastProgressTime *metav1.Time `json:"lastProgressTime,omitempty"`
+
+	// Conditions represent the latest observations of the MiniCloneSet's state
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+const (
+	// ProgressingCondition reports whether the latest rollout is making progress
+	ProgressingCondition = "Progressing"
+
+	// NewRevisionAvailableReason means every pod runs the update revision and is ready
+	NewRevisionAvailableReason = "NewRevisionAvailable"
+	// RolloutInProgressReason means the rollout is still replacing pods
+	RolloutInProgressReason = "RolloutInProgress"
+	// ProgressDeadlineExceededReason means the rollout made no progress within progressDeadlineSeconds
+	ProgressDeadlineExceededReason = "ProgressDeadlineExceeded"
+	// RolloutPausedReason means the rollout is paused at a canary step
+	RolloutPausedReason = "RolloutPaused"
+	// AwaitingApprovalReason means the rollout waits for the update revision to be approved
+	AwaitingApprovalReason = "AwaitingApproval"
+	// OutsideUpdateWindowReason means the rollout waits for an allowed update window
+	OutsideUpdateWindowReason = "OutsideUpdateWindow"
+	// ScheduledScaleReason is the event reason recorded when a scale schedule entry fires
+	ScheduledScaleReason = "ScheduledScale"
+	// HibernatedReason means every pod was deleted because the MiniCloneSet hibernates
+	HibernatedReason = "Hibernated"
+	// WokeUpReason is the event reason recorded when a hibernated MiniCloneSet is restored
+	WokeUpReason = "WokeUp"
+	// PullingImageReason means replacing pods waits for the new image to be pulled onto their nodes
+	PullingImageReason = "PullingImage"
+	// RolloutApprovedReason is the event reason recorded when a gated rollout is approved
+	RolloutApprovedReason = "RolloutApproved"
+
+	// ApproveRevisionAnnotation approves the rollout of the revision hash it is set to
+	ApproveRevisionAnnotation = "apps.example.com.my.domain/approve-revision"
+	// ApprovedByAnnotation optionally names the approver recorded in status. It is not verified.
+	ApprovedByAnnotation = "apps.example.com.my.domain/approved-by"
+
+	// PodsFailedReason means too many updated pods failed and the rollout was reverted
+	PodsFailedReason = "PodsFailed"
+	// RolledBackReason is the event reason recorded when the controller reverts a rollout
+	RolledBackReason = "RolledBack"
+	// ResizeInfeasibleReason is the event reason recorded when a pod cannot be resized in place
+	ResizeInfeasibleReason = "ResizeInfeasible"
+	// RestartFailedReason is the event reason recorded when a container cannot be restarted in place
+	RestartFailedReason = "RestartFailed"
+	// ServiceConflictReason is the event reason recorded when a Service named after the MiniCloneSet is not controlled by it
+	ServiceConflictReason = "ServiceConflict"
+)
+
+// AutoRollbackPolicy configures automatic rollback of a failing rollout
+type AutoRollbackPolicy struct {
+	// MaxFailedPods is the number of failed updated pods that is tolerated.
+	// The rollout is reverted once more than this many updated pods fail.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default=0
+	// +optional
+	MaxFailedPods int `json:"maxFailedPods,omitempty"`
+
+	// WindowSeconds is how long an updated pod may take to become ready
+	// before it counts as failed. Crash-looping pods count as failed immediately.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=300
+	// +optional
+	WindowSeconds int32 `json:"windowSeconds,omitempty"`
+}
+
+// CanaryStrategy describes a rollout that is walked through in steps
+type CanaryStrategy struct {
+	// Steps are executed in order. Once the last step is done the remaining
+	// pods are updated.
+	// +kubebuilder:validation:MinItems=1
+	Steps []CanaryStep `json:"steps"`
+}
+
+// CanaryStep updates a share of the pods and optionally pauses afterwards
+type CanaryStep struct {
+	// Replicas is the number or percentage of pods that run the update
+	// revision once this step is reached
+	// +kubebuilder:validation:XIntOrString
+	Replicas intstr.IntOrString `json:"replicas"`
+
+	// Pause holds the rollout once the step's pods are ready. Unset moves on
+	// to the next step right away.
+	// +optional
+	Pause *CanaryPause `json:"pause,omitempty"`
+}
+
+// CanaryPause describes how long a canary step pauses
+type CanaryPause struct {
+	// DurationSeconds is how long to pause. Unset pauses until the step is
+	// resumed by setting the resume-step annotation to the step index.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	DurationSeconds *int32 `json:"durationSeconds,omitempty"`
+}
+
+// CanaryStepState is the state of a canary step
+type CanaryStepState string
+
+const (
+	// CanaryStepStateUpgrading means pods are being updated up to the step's replicas
+	CanaryStepStateUpgrading CanaryStepState = "StepUpgrading"
+	// CanaryStepStatePaused means the step's pods are ready and the rollout is paused
+	CanaryStepStatePaused CanaryStepState = "StepPaused"
+	// CanaryStepStateCompleted means every step has been executed
+	CanaryStepStateCompleted CanaryStepState = "StepCompleted"
+
+	// RestartingToAnnotation is set on a pod while its containers restart in place to pick up
+	// the pod metadata of the named revision
+	RestartingToAnnotation = "apps.example.com.my.domain/restarting-to"
+	// ResizingToAnnotation is set on a pod while it is resized in place to the named revision
+	ResizingToAnnotation = "apps.example.com.my.domain/resizing-to"
+	// ResumeStepAnnotation resumes a manually paused canary step when set to its index
+	ResumeStepAnnotation = "apps.example.com.my.domain/resume-step"
+)
+
+// BlueGreenStrategy configures a BlueGreen update
+type BlueGreenStrategy struct {
+	// ScaleDownDelaySeconds is how long the previous pods are kept after the
+	// active label has moved, so the switch can be reverted instantly
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default=30
+	// +optional
+	ScaleDownDelaySeconds int32 `json:"scaleDownDelaySeconds,omitempty"`
+}
+
+// ScheduledScale sets the replica count whenever its schedule fires
+type ScheduledScale struct {
+	// Schedule is a cron expression for when the replica count changes
+	// +kubebuilder:validation:MinLength=1
+	Schedule string `json:"schedule"`
+
+	// Replicas is the replica count applied when the schedule fires
+	// +kubebuilder:validation:Minimum=0
+	Replicas int `json:"replicas"`
+
+	// TimeZone is the IANA time zone the schedule is evaluated in, UTC if unset
+	// +optional
+	TimeZone *string `json:"timeZone,omitempty"`
+}
+
+// ScaleScheduleStatus describes the scale schedule entry in effect
+type ScaleScheduleStatus struct {
+	// Index is the position of the entry in spec.scaleSchedule
+	Index int `json:"index"`
+
+	// Schedule is the cron expression of the entry
+	Schedule string `json:"schedule"`
+
+	// Replicas is the replica count the entry applied
+	Replicas int `json:"replicas"`
+
+	// ActivatedAt is when the entry last fired
+	ActivatedAt metav1.Time `json:"activatedAt"`
+
+	// SpecReplicas is spec.replicas at the time the entry fired. Once
+	// spec.replicas differs from it, the manual edit takes priority.
+	SpecReplicas int `json:"specReplicas"`
+}
+
+// SpreadPolicy spreads the pods of a MiniCloneSet across subsets of nodes
+type SpreadPolicy struct {
+	// Subsets in priority order. New pods go to the first subset with room
+	// and scale down removes pods from the last subsets first.
+	// +kubebuilder:validation:MinItems=1
+	// +listType=map
+	// +listMapKey=name
+	Subsets []SpreadSubset `json:"subsets"`
+}
+
+// SpreadSubset is a set of nodes pods can be placed on
+type SpreadSubset struct {
+	// Name identifies the subset in the subset label of its pods
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// RequiredNodeSelectorTerm selects the nodes of the subset
+	RequiredNodeSelectorTerm corev1.NodeSelectorTerm `json:"requiredNodeSelectorTerm"`
+
+	// Tolerations are added to pods of the subset, e.g. for tainted node pools
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// MaxReplicas is the maximum number, or percentage of the desired
+	// replicas, of pods in the subset. Unset means no limit.
+	// +kubebuilder:validation:XIntOrString
+	// +optional
+	MaxReplicas *intstr.IntOrString `json:"maxReplicas,omitempty"`
+}
+
+// PodMetadata is the metadata added to every pod. Keys used by the controller
+// itself, such as app and those under apps.example.com.my.domain/, are ignored.
+type PodMetadata struct {
+	// Labels added to every pod
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Annotations added to every pod
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// ServiceSpec describes the Service the controller manages for a MiniCloneSet
+// +kubebuilder:validation:XValidation:rule="!(has(self.headless) && self.headless) || !has(self.type) || self.type == 'ClusterIP'",message="a headless Service must be of type ClusterIP"
+type ServiceSpec struct {
+	// Type is the type of the Service
+	// +kubebuilder:validation:Enum=ClusterIP;NodePort;LoadBalancer
+	// +kubebuilder:default=ClusterIP
+	// +optional
+	Type corev1.ServiceType `json:"type,omitempty"`
+
+	// Headless creates a Service without a cluster IP, giving each pod its
+	// own DNS record
+	// +optional
+	Headless bool `json:"headless,omitempty"`
+
+	// Ports are the ports the Service exposes. Defaults to port 80 of the
+	// pods, the port of the main container.
+	// +listType=map
+	// +listMapKey=port
+	// +listMapKey=protocol
+	// +optional
+	Ports []ServicePort `json:"ports,omitempty"`
+}
+
+// ServicePort is a port exposed by the Service of a MiniCloneSet
+type ServicePort struct {
+	// Name of the port, required when there is more than one
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Port is the port the Service listens on
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Port int32 `json:"port"`
+
+	// TargetPort is the number or name of the pod port. Defaults to Port.
+	// +kubebuilder:validation:XIntOrString
+	// +optional
+	TargetPort *intstr.IntOrString `json:"targetPort,omitempty"`
+
+	// Protocol of the port
+	// +kubebuilder:validation:Enum=TCP;UDP;SCTP
+	// +kubebuilder:default=TCP
+	// +optional
+	Protocol corev1.Protocol `json:"protocol,omitempty"`
+}
+
+// DisruptionBudget limits voluntary disruptions, such as node drains, of the
+// pods of a MiniCloneSet. Percentages are of the desired replicas, rounded up.
+// +kubebuilder:validation:XValidation:rule="has(self.minAvailable) != has(self.maxUnavailable)",message="exactly one of minAvailable and maxUnavailable must be set"
+type DisruptionBudget struct {
+	// MinAvailable is the number or percentage of pods that must stay available
+	// +kubebuilder:validation:XIntOrString
+	// +optional
+	MinAvailable *intstr.IntOrString `json:"minAvailable,omitempty"`
+
+	// MaxUnavailable is the number or percentage of pods that may be unavailable
+	// +kubebuilder:validation:XIntOrString
+	// +optional
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+}
+
+// PreDeleteHook holds pods back from deletion until other controllers are
+// done with them
+type PreDeleteHook struct {
+	// LabelsHandler labels are added to every new pod. A pod that is about to
+	// be deleted is marked with the PreparingDelete lifecycle state and only
+	// deleted once all of these labels have been removed from it.
+	// +kubebuilder:validation:MinProperties=1
+	LabelsHandler map[string]string `json:"labelsHandler"`
+}
+
+// TeardownStrategy configures deleting the pods of a deleted MiniCloneSet
+type TeardownStrategy struct {
+	// MaxUnavailable is the number or percentage of pods taken down at once
+	// while the MiniCloneSet is being deleted. Percentages are of the desired
+	// replicas, rounded up. Defaults to 25%.
+	// +kubebuilder:validation:XIntOrString
+	// +optional
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+}
+
+// InstanceOverride patches the pods of the instances it selects
+type InstanceOverride struct {
+	// Selector selects pods by ordinal ("3"), ordinal range ("0-2") or name ("web-3")
+	// +kubebuilder:validation:MinLength=1
+	Selector string `json:"selector"`
+
+	// Patch is a strategic merge patch applied to the selected pods, e.g.
+	// {"spec":{"containers":[{"name":"main","env":[{"name":"LOG_LEVEL","value":"debug"}]}]}}
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Patch runtime.RawExtension `json:"patch"`
+}
+
+// ImagePrePullPolicy configures pulling the image of a new revision onto the
+// nodes of the pods before a RollingUpdate replaces them
+type ImagePrePullPolicy struct {
+	// MinPulled is the number or percentage of nodes that must report the
+	// image pulled before pods are replaced. Defaults to 100%.
+	// +kubebuilder:validation:XIntOrString
+	// +optional
+	MinPulled *intstr.IntOrString `json:"minPulled,omitempty"`
+
+	// TimeoutSeconds is how long the rollout waits for MinPulled nodes
+	// before it replaces pods anyway
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=300
+	// +optional
+	TimeoutSeconds int32 `json:"timeoutSeconds,omitempty"`
+}
+
+// ImagePrePullStatus is the progress of pulling the image of a revision
+type ImagePrePullStatus struct {
+	// Revision is the revision whose image is pulled
+	Revision string `json:"revision"`
+
+	// StartedAt is when pulling started
+	StartedAt metav1.Time `json:"startedAt"`
+
+	// Nodes is the number of nodes the image is pulled onto
+	Nodes int `json:"nodes"`
+
+	// NodeNames are the nodes the image is pulled onto, those running
+	// outdated pods when pulling started. They are fixed for the revision, so
+	// replacing pods does not change Nodes and Pulled.
+	// +optional
+	// +listType=set
+	NodeNames []string `json:"nodeNames,omitempty"`
+
+	// Pulled is the number of nodes that reported the image pulled
+	Pulled int `json:"pulled"`
+
+	// Completed is true once pods may be replaced
+	Completed bool `json:"completed"`
+}
+
+// HibernationStatus is the state a hibernating MiniCloneSet is restored to
+type HibernationStatus struct {
+	// Replicas is the replica count before hibernating
+	Replicas int `json:"replicas"`
+
+	// Revision is the revision the pods ran before hibernating
+	Revision string `json:"revision"`
+
+	// HibernatedAt is when the pods were deleted
+	HibernatedAt metav1.Time `json:"hibernatedAt"`
+}
+
+// UpdateWindow is a recurring window of time in which pods may be updated
+type UpdateWindow struct {
+	// Schedule is a cron expression for when the window opens, for example
+	// "0 22 * * 1-5" for 22:00 on weekdays
+	// +kubebuilder:validation:MinLength=1
+	Schedule string `json:"schedule"`
+
+	// DurationSeconds is how long the window stays open
+	// +kubebuilder:validation:Minimum=1
+	DurationSeconds int32 `json:"durationSeconds"`
+
+	// TimeZone is the IANA time zone the schedule is evaluated in, UTC if unset
+	// +optional
+	TimeZone *string `json:"timeZone,omitempty"`
+}
+
+// ApprovalStatus describes the approval of a gated rollout
+type ApprovalStatus struct {
+	// Revision is the approved update revision
+	Revision string `json:"revision"`
+
+	// ApprovedBy is the approver named in the approved-by annotation. Anyone
+	// who can edit the MiniCloneSet can set it, so it is not verified.
+	// +optional
+	ApprovedBy string `json:"approvedBy,omitempty"`
+
+	// FieldManager is the field manager that set the approve-revision
+	// annotation. It names the client, such as kubectl-annotate, not the
+	// user; the API server audit log has the authenticated user.
+	// +optional
+	FieldManager string `json:"fieldManager,omitempty"`
+
+	// ApprovedAt is when the approval was given
+	ApprovedAt metav1.Time `json:"approvedAt"`
+}
+
+// RollbackStatus describes an automatic rollback
+type RollbackStatus struct {
+	// FromRevision is the revision that was reverted
+	FromRevision string `json:"fromRevision"`
+
+	// ToRevision is the revision that was restored
+	ToRevision string `json:"toRevision"`
+
+	// Reason is a machine-readable reason for the rollback
+	Reason string `json:"reason"`
+
+	// Message explains why the rollback happened
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// Time is when the rollback happened
+	Time metav1.Time `json:"time"`
 }
 
 // +kubebuilder:object:root=true
@@ -19,6 +19,7 @@ package main
 import (
 	"crypto/tls"
 	"flag"
+	"fmt"
 	"os"
 	"path/filepath"
 
@@ -40,6 +41,8 @@ import (
 	appsexamplecomv1alpha1 "k8s.openkruise.com/v1/api/v1alpha1"
 	appsexamplecomv1beta1 "k8s.openkruise.com/v1/api/v1beta1"
 	"k8s.openkruise.com/v1/internal/controller"
+	webhookv1 "k8s.openkruise.com/v1/internal/webhook/v1"
+	webhookv1alpha1 "k8s.openkruise.com/v1/internal/webhook/v1alpha1"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -205,12 +208,50 @@ func main() {
 	}
 
 	if err := (&controller.MiniCloneSetReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("minicloneset-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "MiniCloneSet")
+		os.Exit(1)
+	}
+	if err := (&controller.MiniSidecarSetReconciler{
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
 	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "MiniCloneSet")
+		setupLog.Error(err, "unable to create controller", "controller", "MiniSidecarSet")
 		os.Exit(1)
 	}
+	if err := (&controller.MiniUnitedSetReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "MiniUnitedSet")
+		os.Exit(1)
+	}
+	if err := (&controller.ContainerRestartRequestReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ContainerRestartRequest")
+		os.Exit(1)
+	}
+	// nolint:goconst
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		// Pod deletes made by the controller itself are exempt from the unavailable budget
+		var controllerUsername string
+		if namespace, serviceAccount := os.Getenv("POD_NAMESPACE"), os.Getenv("SERVICE_ACCOUNT_NAME"); namespace != "" && serviceAccount != "" {
+			controllerUsername = fmt.Sprintf("system:serviceaccount:%s:%s", namespace, serviceAccount)
+		}
+		if err := webhookv1.SetupPodWebhookWithManager(mgr, controllerUsername); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Pod")
+			os.Exit(1)
+		}
+		if err := webhookv1alpha1.SetupMiniCloneSetWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "MiniCloneSet")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
 	if metricsCertWatcher != nil {
@@ -0,0 +1,225 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	appsexamplecomv1alpha1 "k8s.openkruise.com/v1/api/v1alpha1"
+)
+
+// ContainerRestartRequestReconciler reconciles a ContainerRestartRequest object
+type ContainerRestartRequestReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+// restartBudgetRequeue is how long a request waits for the disruption budget
+// of its MiniCloneSet to allow the restart
+const restartBudgetRequeue = time.Second * 10
+
+// +kubebuilder:rbac:groups=apps.example.com.my.domain,resources=containerrestartrequests,verbs=get;list;watch
+// +kubebuilder:rbac:groups=apps.example.com.my.domain,resources=containerrestartrequests/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=apps.example.com.my.domain,resources=miniclonesets,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=pods/ephemeralcontainers,verbs=update
+
+// Reconcile restarts the containers named by a ContainerRestartRequest in
+// place, the same way pod metadata changes restart containers, and tracks
+// them until they are ready again. The restart waits while it would take the
+// MiniCloneSet below its disruption budget. Completed and failed requests
+// are left alone.
+func (r *ContainerRestartRequestReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	var request appsexamplecomv1alpha1.ContainerRestartRequest
+	if err := r.Get(ctx, req.NamespacedName, &request); err != nil {
+		log.Error(err, "unable to fetch ContainerRestartRequest")
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if request.Status.Phase == appsexamplecomv1alpha1.RestartCompleted || request.Status.Phase == appsexamplecomv1alpha1.RestartFailed {
+		return ctrl.Result{}, nil
+	}
+
+	var pod corev1.Pod
+	if err := r.Get(ctx, types.NamespacedName{Namespace: request.Namespace, Name: request.Spec.PodName}, &pod); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, r.fail(ctx, &request, fmt.Sprintf("pod %s not found", request.Spec.PodName))
+	}
+	if msg := checkRestartable(&pod, request.Spec.Containers); msg != "" {
+		return ctrl.Result{}, r.fail(ctx, &request, msg)
+	}
+
+	round := restartRound(&request)
+	if request.Status.Phase != appsexamplecomv1alpha1.RestartRestarting {
+		if len(request.Spec.Containers) > restartersLeft(&pod) {
+			return ctrl.Result{}, r.fail(ctx, &request, fmt.Sprintf(
+				"pod %s was restarted in place %d times already, delete it to restart it again", pod.Name, maxRestarters))
+		}
+		reason, err := r.restartBudgetExceeded(ctx, &pod)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if reason != "" {
+			if request.Status.Phase != appsexamplecomv1alpha1.RestartPending || request.Status.Message != reason {
+				request.Status.Phase = appsexamplecomv1alpha1.RestartPending
+				request.Status.Message = reason
+				if err := r.Status().Update(ctx, &request); err != nil {
+					return ctrl.Result{}, err
+				}
+			}
+			log.Info("Waiting for the disruption budget to restart containers", "pod", pod.Name, "reason", reason)
+			return ctrl.Result{RequeueAfter: restartBudgetRequeue}, nil
+		}
+	}
+	if err := addRestarters(ctx, r.Client, &pod, request.Spec.Containers, round); err != nil {
+		if budgetDenied(err) {
+			log.Info("Disruption budget denied restarting containers, retrying later", "pod", pod.Name)
+			return ctrl.Result{RequeueAfter: restartBudgetRequeue}, nil
+		}
+		log.Error(err, "failed to restart containers", "pod", pod.Name)
+		return ctrl.Result{}, err
+	}
+
+	request.Status.Phase = appsexamplecomv1alpha1.RestartRestarting
+	request.Status.Message = ""
+	request.Status.ContainerStates = nil
+	completed := true
+	for _, container := range request.Spec.Containers {
+		done, failed := restartState(&pod, container, round, time.Now())
+		if failed {
+			return ctrl.Result{}, r.fail(ctx, &request, fmt.Sprintf("container %s could not be restarted", container))
+		}
+		completed = completed && done
+		request.Status.ContainerStates = append(request.Status.ContainerStates,
+			appsexamplecomv1alpha1.ContainerRestartState{Name: container, Restarted: done})
+	}
+	if completed {
+		request.Status.Phase = appsexamplecomv1alpha1.RestartCompleted
+		now := metav1.Now()
+		request.Status.CompletionTime = &now
+	}
+	if err := r.Status().Update(ctx, &request); err != nil {
+		log.Error(err, "failed to update ContainerRestartRequest status")
+		return ctrl.Result{}, err
+	}
+	if completed {
+		log.Info("Restarted containers", "pod", pod.Name, "containers", request.Spec.Containers)
+		return ctrl.Result{}, nil
+	}
+	return ctrl.Result{RequeueAfter: time.Second * 5}, nil
+}
+
+// fail marks a ContainerRestartRequest as failed with the given reason
+func (r *ContainerRestartRequestReconciler) fail(ctx context.Context, request *appsexamplecomv1alpha1.ContainerRestartRequest, msg string) error {
+	logf.FromContext(ctx).Info("ContainerRestartRequest failed", "reason", msg)
+	now := metav1.Now()
+	request.Status.Phase = appsexamplecomv1alpha1.RestartFailed
+	request.Status.Message = msg
+	request.Status.CompletionTime = &now
+	return r.Status().Update(ctx, request)
+}
+
+// restartBudgetExceeded returns why restarting the pod would take its
+// MiniCloneSet below spec.disruptionBudget, or an empty string if it would
+// not. Pods that are not ready or whose restarters are still running count as
+// unavailable.
+func (r *ContainerRestartRequestReconciler) restartBudgetExceeded(ctx context.Context, pod *corev1.Pod) (string, error) {
+	if !isPodReady(pod) || restartInFlight(pod) {
+		return "", nil
+	}
+	owner := metav1.GetControllerOf(pod)
+	var cloneSet appsexamplecomv1alpha1.MiniCloneSet
+	if err := r.Get(ctx, types.NamespacedName{Namespace: pod.Namespace, Name: owner.Name}, &cloneSet); err != nil {
+		return "", client.IgnoreNotFound(err)
+	}
+	if cloneSet.UID != owner.UID || cloneSet.Spec.DisruptionBudget == nil {
+		return "", nil
+	}
+
+	desiredReplicas := cloneSet.Spec.Replicas
+	if cloneSet.Status.ObservedGeneration > 0 {
+		desiredReplicas = cloneSet.Status.DesiredReplicas
+	}
+	minAvailable, err := cloneSet.Spec.DisruptionBudget.MinAvailablePods(desiredReplicas)
+	if err != nil {
+		return "", err
+	}
+
+	var pods corev1.PodList
+	if err := r.List(ctx, &pods, client.InNamespace(pod.Namespace), client.MatchingLabels{"app": cloneSet.Name}); err != nil {
+		return "", err
+	}
+	available := 0
+	for i := range pods.Items {
+		p := &pods.Items[i]
+		if metav1.IsControlledBy(p, &cloneSet) && p.DeletionTimestamp == nil && isPodReady(p) && !restartInFlight(p) {
+			available++
+		}
+	}
+	if available-1 < minAvailable {
+		return fmt.Sprintf("MiniCloneSet %s needs %d available pods and has %d", cloneSet.Name, minAvailable, available), nil
+	}
+	return "", nil
+}
+
+// checkRestartable returns why the containers of the pod cannot be restarted,
+// or an empty string if they can
+func checkRestartable(pod *corev1.Pod, containers []string) string {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil || owner.Kind != "MiniCloneSet" || owner.APIVersion != appsexamplecomv1alpha1.GroupVersion.String() {
+		return fmt.Sprintf("pod %s is not managed by a MiniCloneSet", pod.Name)
+	}
+	if pod.DeletionTimestamp != nil {
+		return fmt.Sprintf("pod %s is being deleted", pod.Name)
+	}
+	for _, container := range containers {
+		if !slices.ContainsFunc(pod.Spec.Containers, func(c corev1.Container) bool { return c.Name == container }) {
+			return fmt.Sprintf("pod %s has no container %s", pod.Name, container)
+		}
+	}
+	return ""
+}
+
+// restartRound returns the round the restarters of a request are named
+// after, unique to the request
+func restartRound(request *appsexamplecomv1alpha1.ContainerRestartRequest) string {
+	uid := string(request.UID)
+	return uid[:min(len(uid), 8)]
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ContainerRestartRequestReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&appsexamplecomv1alpha1.ContainerRestartRequest{}).
+		Named("containerrestartrequest").
+		Complete(r)
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	appsexamplecomv1alpha1 "k8s.openkruise.com/v1/api/v1alpha1"
+)
+
+var _ = Describe("ContainerRestartRequest Controller", func() {
+	newReconciler := func(objs ...client.Object) *ContainerRestartRequestReconciler {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(appsexamplecomv1alpha1.AddToScheme(scheme)).To(Succeed())
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).
+			WithStatusSubresource(&appsexamplecomv1alpha1.ContainerRestartRequest{}).Build()
+		return &ContainerRestartRequestReconciler{Client: c, Scheme: scheme}
+	}
+	newRequest := func() *appsexamplecomv1alpha1.ContainerRestartRequest {
+		return &appsexamplecomv1alpha1.ContainerRestartRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: "bounce", Namespace: "default", UID: "0123456789"},
+			Spec:       appsexamplecomv1alpha1.ContainerRestartRequestSpec{PodName: "web-0", Containers: []string{"main"}},
+		}
+	}
+	reconcileRequest := func(r *ContainerRestartRequestReconciler, request *appsexamplecomv1alpha1.ContainerRestartRequest) {
+		_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(request)})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(r.Get(ctx, client.ObjectKeyFromObject(request), request)).To(Succeed())
+	}
+
+	It("should fail for pods not managed by a MiniCloneSet", func() {
+		request := newRequest()
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-0", Namespace: "default"}}
+		r := newReconciler(request, pod)
+
+		reconcileRequest(r, request)
+		Expect(request.Status.Phase).To(Equal(appsexamplecomv1alpha1.RestartFailed))
+		Expect(request.Status.Message).To(ContainSubstring("not managed by a MiniCloneSet"))
+	})
+
+	It("should complete once the container restarted after its restarter", func() {
+		request := newRequest()
+		request.Status.Phase = appsexamplecomv1alpha1.RestartRestarting
+		finishedAt := metav1.NewTime(time.Now().Truncate(time.Second))
+		restarter := restarterName("main", restartRound(request))
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "web-0",
+				Namespace: "default",
+				OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(
+					&appsexamplecomv1alpha1.MiniCloneSet{ObjectMeta: metav1.ObjectMeta{Name: "web", UID: "web"}},
+					appsexamplecomv1alpha1.GroupVersion.WithKind("MiniCloneSet"),
+				)},
+			},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "main", Image: "nginx:1.20"}},
+				EphemeralContainers: []corev1.EphemeralContainer{{EphemeralContainerCommon: corev1.EphemeralContainerCommon{
+					Name: restarter,
+					Env:  []corev1.EnvVar{{Name: restartCountEnv, Value: "0"}},
+				}}},
+			},
+			Status: corev1.PodStatus{
+				EphemeralContainerStatuses: []corev1.ContainerStatus{{
+					Name:  restarter,
+					State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{FinishedAt: finishedAt}},
+				}},
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name:         "main",
+					Ready:        true,
+					RestartCount: 1,
+					State:        corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: finishedAt}},
+				}},
+			},
+		}
+		r := newReconciler(request, pod)
+
+		reconcileRequest(r, request)
+		Expect(request.Status.Phase).To(Equal(appsexamplecomv1alpha1.RestartCompleted))
+		Expect(request.Status.ContainerStates).To(ConsistOf(appsexamplecomv1alpha1.ContainerRestartState{Name: "main", Restarted: true}))
+		Expect(request.Status.CompletionTime).NotTo(BeNil())
+	})
+	It("should wait while the restart would exceed the disruption budget", func() {
+		maxUnavailable := intstr.FromInt32(1)
+		cloneSet := &appsexamplecomv1alpha1.MiniCloneSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", UID: "web"},
+			Spec: appsexamplecomv1alpha1.MiniCloneSetSpec{
+				Replicas:         2,
+				DisruptionBudget: &appsexamplecomv1alpha1.DisruptionBudget{MaxUnavailable: &maxUnavailable},
+			},
+		}
+		newPod := func(name string, ready corev1.ConditionStatus) *corev1.Pod {
+			return &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            name,
+					Namespace:       "default",
+					Labels:          map[string]string{"app": "web"},
+					OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(cloneSet, appsexamplecomv1alpha1.GroupVersion.WithKind("MiniCloneSet"))},
+				},
+				Spec:   corev1.PodSpec{Containers: []corev1.Container{{Name: "main", Image: "nginx:1.20"}}},
+				Status: corev1.PodStatus{Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: ready}}},
+			}
+		}
+		request := newRequest()
+		r := newReconciler(request, cloneSet, newPod("web-0", corev1.ConditionTrue), newPod("web-1", corev1.ConditionFalse))
+
+		reconcileRequest(r, request)
+		Expect(request.Status.Phase).To(Equal(appsexamplecomv1alpha1.RestartPending))
+		Expect(request.Status.Message).To(ContainSubstring("needs 1 available pods"))
+		pod := &corev1.Pod{}
+		Expect(r.Get(ctx, client.ObjectKey{Namespace: "default", Name: "web-0"}, pod)).To(Succeed())
+		Expect(pod.Spec.EphemeralContainers).To(BeEmpty())
+	})
+})
@@ -0,0 +1,106 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appsexamplecomv1alpha1 "k8s.openkruise.com/v1/api/v1alpha1"
+)
+
+// syncApproval checks if the update revision may be rolled out. When the
+// MiniCloneSet requires approval and the approve-revision annotation names the
+// update revision, the approval is recorded in status.
+func (r *MiniCloneSetReconciler) syncApproval(myCR *appsexamplecomv1alpha1.MiniCloneSet) bool {
+	status := &myCR.Status
+	if !awaitingApproval(myCR) {
+		return true
+	}
+	if myCR.Annotations[appsexamplecomv1alpha1.ApproveRevisionAnnotation] != status.UpdateRevision {
+		return false
+	}
+
+	approvedBy, fieldManager, approvedAt := approver(myCR)
+	status.LastApproval = &appsexamplecomv1alpha1.ApprovalStatus{
+		Revision:     status.UpdateRevision,
+		ApprovedBy:   approvedBy,
+		FieldManager: fieldManager,
+		ApprovedAt:   approvedAt,
+	}
+	r.Recorder.Eventf(myCR, corev1.EventTypeNormal, appsexamplecomv1alpha1.RolloutApprovedReason,
+		"Revision %s approved by %q through field manager %q", status.UpdateRevision, approvedBy, fieldManager)
+	return true
+}
+
+// awaitingApproval checks if a gated rollout has not been approved yet. The
+// first rollout of a new MiniCloneSet does not need approval.
+func awaitingApproval(myCR *appsexamplecomv1alpha1.MiniCloneSet) bool {
+	status := &myCR.Status
+	if !myCR.Spec.RequireApproval || status.CurrentRevision == "" || status.CurrentRevision == status.UpdateRevision {
+		return false
+	}
+	return status.LastApproval == nil || status.LastApproval.Revision != status.UpdateRevision
+}
+
+// approver returns the approver named in the approved-by annotation, the
+// field manager that last wrote the approve-revision annotation and when it
+// did so. Neither is an authenticated identity: the annotation can be set by
+// anyone who can edit the MiniCloneSet and the field manager names a client.
+func approver(myCR *appsexamplecomv1alpha1.MiniCloneSet) (string, string, metav1.Time) {
+	approvedBy := myCR.Annotations[appsexamplecomv1alpha1.ApprovedByAnnotation]
+	approvedAt := metav1.Now()
+	fieldManager := ""
+
+	var latest *metav1.ManagedFieldsEntry
+	for i, entry := range myCR.ManagedFields {
+		if !ownsAnnotation(&entry, appsexamplecomv1alpha1.ApproveRevisionAnnotation) || entry.Time == nil {
+			continue
+		}
+		if latest == nil || entry.Time.After(latest.Time.Time) {
+			latest = &myCR.ManagedFields[i]
+		}
+	}
+	if latest != nil {
+		approvedAt = *latest.Time
+		fieldManager = latest.Manager
+	}
+	return approvedBy, fieldManager, approvedAt
+}
+
+// ownsAnnotation checks if a managed fields entry owns the given annotation
+func ownsAnnotation(entry *metav1.ManagedFieldsEntry, key string) bool {
+	if entry.FieldsV1 == nil {
+		return false
+	}
+	var fields interface{}
+	if err := json.Unmarshal(entry.FieldsV1.Raw, &fields); err != nil {
+		return false
+	}
+	for _, name := range []string{"f:metadata", "f:annotations", "f:" + key} {
+		set, ok := fields.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		if fields, ok = set[name]; !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	appsexamplecomv1alpha1 "k8s.openkruise.com/v1/api/v1alpha1"
+)
+
+// syncCanary walks the canary steps for the update revision and returns how
+// many pods may run it right now. Without canary steps, or when there is no
+// previous revision to protect, every pod may be updated.
+func (r *MiniCloneSetReconciler) syncCanary(ctx context.Context, myCR *appsexamplecomv1alpha1.MiniCloneSet, podList *corev1.PodList, desiredReplicas int) (int, ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+	status := &myCR.Status
+
+	if myCR.Spec.Canary == nil || status.CurrentRevision == "" || status.CurrentRevision == status.UpdateRevision {
+		resetCanaryStatus(status)
+		return desiredReplicas, ctrl.Result{}, nil
+	}
+
+	updatedReadyPods := 0
+	for _, pod := range podList.Items {
+		if isPodUpToDate(&pod, status.UpdateRevision) && isPodReady(&pod) {
+			updatedReadyPods++
+		}
+	}
+
+	if status.CurrentStepIndex == nil {
+		status.CurrentStepIndex = new(int32)
+	}
+	steps := myCR.Spec.Canary.Steps
+	for int(*status.CurrentStepIndex) < len(steps) {
+		index := *status.CurrentStepIndex
+		step := steps[index]
+		target, err := intstr.GetScaledValueFromIntOrPercent(&step.Replicas, desiredReplicas, true)
+		if err != nil {
+			return 0, ctrl.Result{}, err
+		}
+		target = min(target, desiredReplicas)
+
+		if updatedReadyPods < target {
+			status.CurrentStepState = appsexamplecomv1alpha1.CanaryStepStateUpgrading
+			status.CurrentStepPausedAt = nil
+			return target, ctrl.Result{}, nil
+		}
+
+		if step.Pause != nil {
+			if status.CurrentStepPausedAt == nil {
+				now := metav1.Now()
+				status.CurrentStepPausedAt = &now
+				log.Info("Canary step reached, pausing", "step", index, "replicas", target)
+			}
+			status.CurrentStepState = appsexamplecomv1alpha1.CanaryStepStatePaused
+
+			if step.Pause.DurationSeconds != nil {
+				resumeAt := status.CurrentStepPausedAt.Add(time.Duration(*step.Pause.DurationSeconds) * time.Second)
+				if remaining := time.Until(resumeAt); remaining > 0 {
+					return target, ctrl.Result{RequeueAfter: remaining}, nil
+				}
+			} else {
+				if myCR.Annotations[appsexamplecomv1alpha1.ResumeStepAnnotation] != strconv.Itoa(int(index)) {
+					return target, ctrl.Result{}, nil
+				}
+				// Consume the annotation so it cannot resume the same step of a later rollout
+				resumed := myCR.DeepCopy()
+				delete(resumed.Annotations, appsexamplecomv1alpha1.ResumeStepAnnotation)
+				if err := r.Patch(ctx, resumed, client.MergeFrom(myCR)); err != nil {
+					return 0, ctrl.Result{}, err
+				}
+				myCR.ObjectMeta = resumed.ObjectMeta
+			}
+		}
+
+		log.Info("Canary step completed", "step", index, "replicas", target)
+		*status.CurrentStepIndex = index + 1
+		status.CurrentStepPausedAt = nil
+	}
+
+	status.CurrentStepState = appsexamplecomv1alpha1.CanaryStepStateCompleted
+	return desiredReplicas, ctrl.Result{}, nil
+}
+
+// resetCanaryStatus clears the canary progress so the steps start over
+func resetCanaryStatus(status *appsexamplecomv1alpha1.MiniCloneSetStatus) {
+	status.CurrentStepIndex = nil
+	status.CurrentStepState = ""
+	status.CurrentStepPausedAt = nil
+}
@@ -1,18 +1,7 @@
 /*
 Copyright 2025.
 
-import (
-	"context"
-
-	"k8s.io/apimachinery/pkg/runtime"
-	ctrl "sigs.k8s.io/controller-runtime"
-	"sigs.k8s.io/controller-runtime/pkg/client"
-	logf "sigs.k8s.io/controller-runtime/pkg/log"
-
-	appsexamplecomv1beta1 "k8s.openkruise.com/v1/api/v1beta1"
-).k8s.io/controller-runtime/pkg/log"
-
-	appsexamplecomv1beta1 "k8s.openkruise.com/v1/api/v1beta1" under the Apache License, Version 2.0 (the "License");
+Licensed under the Apache License, Version 2.0 (the "License");
 you may not use this file except in compliance with the License.
 You may obtain a copy of the License at
 
@@ -30,35 +19,57 @@ package controller
 import (
 	"context"
 	"fmt"
+	"slices"
 	"time"
 
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 
 	appsexamplecomv1alpha1 "k8s.openkruise.com/v1/api/v1alpha1"
 )
 
+// defaultScaleDownDelay is how long old pods are kept after a blue/green switch
+// when the MiniCloneSet does not configure it
+const defaultScaleDownDelay = 30 * time.Second
+
 // MiniCloneSetReconciler reconciles a MiniCloneSet object
 type MiniCloneSetReconciler struct {
 	client.Client
-	Scheme *runtime.Scheme
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+
+	// updateLimiters paces pod replacements of rolling updates
+	updateLimiters updateRateLimiters
 }
 
 // +kubebuilder:rbac:groups=apps.example.com.my.domain,resources=miniclonesets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=apps.example.com.my.domain,resources=miniclonesets/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=apps.example.com.my.domain,resources=miniclonesets/finalizers,verbs=update
 // +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=pods/resize,verbs=patch
+// +kubebuilder:rbac:groups="",resources=pods/ephemeralcontainers,verbs=update
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch
+// +kubebuilder:rbac:groups=apps,resources=controllerrevisions,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=apps.example.com.my.domain,resources=minisidecarsets,verbs=get;list;watch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
-// TODO(user): Modify the Reconcile function to compare the state specified by
-// the MiniCloneSet object against the actual cluster state, and then
-// perform operations to make the cluster state reflect the state specified by
-// the user.
+// It lists the pods owned by the MiniCloneSet, hands them to the handler for the
+// configured update strategy and then reports the observed state in status.
 //
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.21.0/pkg/reconcile
@@ -68,67 +79,265 @@ func (r *MiniCloneSetReconciler) Reconcile(ctx context.Context, req ctrl.Request
 	// Fetch the MiniCloneSet instance
 	var myCR appsexamplecomv1alpha1.MiniCloneSet
 	if err := r.Get(ctx, req.NamespacedName, &myCR); err != nil {
+		if apierrors.IsNotFound(err) {
+			r.updateLimiters.forget(req.NamespacedName)
+		}
 		log.Error(err, "unable to fetch MiniCloneSet")
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
-	// Log Replicas and Image as requested
+	// A deleted MiniCloneSet is scaled down in batches before it goes away
+	if !myCR.DeletionTimestamp.IsZero() {
+		return r.teardown(ctx, &myCR)
+	}
+	if controllerutil.AddFinalizer(&myCR, appsexamplecomv1alpha1.TeardownFinalizer) {
+		if err := r.Update(ctx, &myCR); err != nil {
+			log.Error(err, "failed to add the teardown finalizer")
+			return ctrl.Result{}, err
+		}
+	}
+
 	log.Info("Reconciling MiniCloneSet",
 		"replicas", myCR.Spec.Replicas,
-		"image", myCR.Spec.Image) // Fake status update: set AvailableReplicas = Replicas
-	myCR.Status.AvailableReplicas = myCR.Spec.Replicas
-	if err := r.Status().Update(ctx, &myCR); err != nil {
-		log.Error(err, "failed to update MiniCloneSet status")
+		"image", myCR.Spec.Image)
+
+	// The scale schedule may override spec.replicas
+	desiredReplicas, untilScheduledScale, err := r.syncScaleSchedule(&myCR, time.Now())
+	if err != nil {
+		log.Error(err, "failed to evaluate scale schedule")
 		return ctrl.Result{}, err
 	}
+	myCR.Status.DesiredReplicas = desiredReplicas
 
-	// Return empty result as requested
-	return ctrl.Result{}, nil
+	if err := r.syncService(ctx, &myCR); err != nil {
+		log.Error(err, "failed to sync Service")
+		return ctrl.Result{}, err
+	}
+
+	podList, terminating, err := r.listPods(ctx, &myCR)
+	if err != nil {
+		log.Error(err, "failed to list pods")
+		return ctrl.Result{}, err
+	}
+
+	// In PerNode mode there is one pod for every matching node
+	if myCR.Spec.Mode == appsexamplecomv1alpha1.PerNodeMode {
+		if desiredReplicas, err = r.syncNodes(ctx, &myCR, podList); err != nil {
+			log.Error(err, "failed to sync pods with nodes")
+			return ctrl.Result{}, err
+		}
+		myCR.Status.DesiredReplicas = desiredReplicas
+	}
+
+	if err := r.syncDisruptionBudget(ctx, &myCR, desiredReplicas); err != nil {
+		log.Error(err, "failed to sync PodDisruptionBudget")
+		return ctrl.Result{}, err
+	}
+
+	updateRevision, err := r.syncRevision(ctx, &myCR)
+	if err != nil {
+		log.Error(err, "failed to sync revisions")
+		return ctrl.Result{}, err
+	}
+	if myCR.Status.UpdateRevision != updateRevision {
+		resetCanaryStatus(&myCR.Status)
+	}
+	myCR.Status.UpdateRevision = updateRevision
+
+	// A hibernating MiniCloneSet runs no pods until it has been woken up
+	hibernating, result, err := r.syncHibernation(ctx, &myCR, podList, terminating, desiredReplicas)
+	if err != nil {
+		log.Error(err, "failed to sync hibernation")
+		return ctrl.Result{}, err
+	}
+	if hibernating {
+		return r.updateStatus(ctx, &myCR, podList, result)
+	}
+
+	// Revert a failing rollout before touching any more pods
+	rolledBack, err := r.autoRollback(ctx, &myCR, podList)
+	if err != nil || rolledBack {
+		return ctrl.Result{}, err
+	}
+
+	// Canary steps cap how many pods may run the update revision
+	maxUpdated, result, err := r.syncCanary(ctx, &myCR, podList, desiredReplicas)
+	if err != nil {
+		log.Error(err, "failed to sync canary steps")
+		return ctrl.Result{}, err
+	}
+
+	// Outside of the allowed update windows only scaling is done
+	inWindow, nextWindowStart, err := updateWindowOpen(myCR.Spec.AllowedWindows, time.Now())
+	if err != nil {
+		log.Error(err, "failed to evaluate update windows")
+		return ctrl.Result{}, err
+	}
+	myCR.Status.NextWindowStartTime = nil
+	myCR.Status.NextPodUpdateTime = nil
+	if !inWindow {
+		myCR.Status.NextWindowStartTime = &metav1.Time{Time: nextWindowStart}
+	}
+
+	var handlerResult ctrl.Result
+	switch {
+	case terminating > 0:
+		// Wait for terminating pods to go away so replacements can reuse their names
+		log.Info("Waiting for pods to terminate", "terminating", terminating)
+		handlerResult = ctrl.Result{RequeueAfter: time.Second * 5}
+	case !r.syncApproval(&myCR):
+		// Leave outdated pods alone until a human approves the update revision,
+		// and keep scaling with pods of the current revision meanwhile
+		log.Info("Waiting for rollout approval, only scaling", "revision", updateRevision)
+		var current *appsexamplecomv1alpha1.MiniCloneSet
+		if current, err = r.templateForRevision(ctx, &myCR, myCR.Status.CurrentRevision); err == nil {
+			handlerResult, err = r.handleScale(ctx, current, podList, desiredReplicas, myCR.Status.CurrentRevision)
+		}
+	case !inWindow && myCR.Status.CurrentRevision != updateRevision:
+		log.Info("Outside of the allowed update windows, only scaling", "nextWindow", nextWindowStart)
+		handlerResult, err = r.handleScale(ctx, &myCR, podList, desiredReplicas, updateRevision)
+		result = requeueNoLaterThan(result, time.Until(nextWindowStart))
+	case myCR.Spec.UpdateStrategy == appsexamplecomv1alpha1.RecreateStrategyType:
+		handlerResult, err = r.handleRecreateUpdate(ctx, &myCR, podList, desiredReplicas, updateRevision)
+	case myCR.Spec.UpdateStrategy == appsexamplecomv1alpha1.BlueGreenStrategyType:
+		handlerResult, err = r.handleBlueGreenUpdate(ctx, &myCR, podList, desiredReplicas, updateRevision)
+	case myCR.Spec.StableIdentity:
+		handlerResult, err = r.handleOrdinalUpdate(ctx, &myCR, podList, desiredReplicas, updateRevision, maxUpdated)
+	default:
+		handlerResult, err = r.handleRollingUpdate(ctx, &myCR, podList, desiredReplicas, updateRevision, maxUpdated)
+	}
+	if err != nil {
+		return handlerResult, err
+	}
+	if handlerResult.RequeueAfter > 0 {
+		result = requeueNoLaterThan(result, handlerResult.RequeueAfter)
+	}
+	if untilScheduledScale > 0 {
+		result = requeueNoLaterThan(result, untilScheduledScale)
+	}
+
+	return r.updateStatus(ctx, &myCR, podList, result)
 }
 
-// handleRollingUpdate implements rolling update strategy
-func (r *MiniCloneSetReconciler) handleRollingUpdate(ctx context.Context, myCR *appsexamplecomv1alpha1.MiniCloneSet, podList *corev1.PodList, desiredReplicas int, desiredImage string) (ctrl.Result, error) {
+// listPods returns the active pods controlled by the MiniCloneSet and the
+// number of controlled pods that are still terminating
+func (r *MiniCloneSetReconciler) listPods(ctx context.Context, myCR *appsexamplecomv1alpha1.MiniCloneSet) (*corev1.PodList, int, error) {
+	var allPods corev1.PodList
+	if err := r.List(ctx, &allPods, client.InNamespace(myCR.Namespace), client.MatchingLabels{"app": myCR.Name}); err != nil {
+		return nil, 0, err
+	}
+
+	podList := &corev1.PodList{}
+	terminating := 0
+	for _, pod := range allPods.Items {
+		if !metav1.IsControlledBy(&pod, myCR) {
+			continue
+		}
+		if pod.DeletionTimestamp != nil {
+			terminating++
+			continue
+		}
+		podList.Items = append(podList.Items, pod)
+	}
+	if myCR.Spec.Mode == appsexamplecomv1alpha1.PerNodeMode {
+		sortByNode(podList.Items)
+	}
+	return podList, terminating, nil
+}
+
+// handleRollingUpdate implements rolling update strategy. At most maxUpdated
+// pods are moved to the desired revision, which is how canary steps hold a
+// rollout part-way.
+func (r *MiniCloneSetReconciler) handleRollingUpdate(ctx context.Context, myCR *appsexamplecomv1alpha1.MiniCloneSet, podList *corev1.PodList, desiredReplicas int, desiredRevision string, maxUpdated int) (ctrl.Result, error) {
 	log := logf.FromContext(ctx)
 
-	// Count pods by status
-	readyPods := 0
+	// Collect pods that were created from an outdated revision
 	outdatedPods := []corev1.Pod{}
+	updatedPods := 0
+	updatedReady := true
 
 	for _, pod := range podList.Items {
-		if isPodReady(&pod) {
-			readyPods++
-		}
-		if !isPodUpToDate(&pod, desiredImage) {
+		if !isPodUpToDate(&pod, desiredRevision) {
 			outdatedPods = append(outdatedPods, pod)
+			continue
+		}
+		updatedPods++
+		if !isPodReady(&pod) {
+			updatedReady = false
 		}
 	}
 
 	// Scale up if we need more pods
 	currentPods := len(podList.Items)
 	if currentPods < desiredReplicas {
-		for i := currentPods; i < desiredReplicas; i++ {
-			pod := r.createPodForMiniCloneSet(myCR, i)
+		for range podsToCreate(myCR, podList, desiredReplicas-currentPods) {
+			pod, err := r.createPodForMiniCloneSet(ctx, myCR, podList, desiredRevision)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
 			if err := r.Create(ctx, pod); err != nil {
 				log.Error(err, "failed to create pod", "pod", pod.Name)
 				return ctrl.Result{}, err
 			}
+			podList.Items = append(podList.Items, *pod)
 			log.Info("Created new pod", "pod", pod.Name)
 		}
 		// Requeue to check status
 		return ctrl.Result{RequeueAfter: time.Second * 10}, nil
 	}
 
-	// Rolling update: replace outdated pods one by one
-	if len(outdatedPods) > 0 {
+	// Pods whose template only differs in resources or pod metadata are updated in place
+	if resizing, result, err := r.syncInPlaceResize(ctx, myCR, podList, desiredRevision, maxUpdated); resizing || err != nil {
+		return result, err
+	}
+	if updating, result, err := r.syncInPlaceMetadata(ctx, myCR, podList, desiredRevision, maxUpdated); updating || err != nil {
+		return result, err
+	}
+
+	// Rolling update: replace outdated pods one by one, up to maxUpdated
+	if len(outdatedPods) > 0 && (currentPods > desiredReplicas || updatedPods < maxUpdated) {
+		// Wait for the new image to be on the nodes before taking pods down
+		if pulled, err := r.syncImagePrePull(ctx, myCR, podList, desiredRevision, time.Now()); err != nil || !pulled {
+			return ctrl.Result{RequeueAfter: time.Second * 5}, err
+		}
+
 		// Only update one pod at a time for rolling update
 		podToUpdate := outdatedPods[0]
 
+		// A replacement pod already exists, so delete the old one once it is ready
+		if currentPods > desiredReplicas {
+			if !updatedReady {
+				// Wait for new pod to be ready before deleting old one
+				return ctrl.Result{RequeueAfter: time.Second * 5}, nil
+			}
+			if err := r.Delete(ctx, &podToUpdate); err != nil {
+				if budgetDenied(err) {
+					log.Info("Disruption budget denied deleting pod, retrying later", "pod", podToUpdate.Name)
+					return ctrl.Result{RequeueAfter: budgetRequeue}, nil
+				}
+				log.Error(err, "failed to delete outdated pod", "pod", podToUpdate.Name)
+				return ctrl.Result{}, client.IgnoreNotFound(err)
+			}
+			log.Info("Deleted outdated pod for rolling update", "pod", podToUpdate.Name)
+			return ctrl.Result{RequeueAfter: time.Second * 5}, nil
+		}
+
+		// Respect the pod update rate limit before starting another replacement
+		if delay := r.reservePodUpdate(myCR); delay > 0 {
+			log.Info("Pod update rate limit reached", "oldPod", podToUpdate.Name, "retryAfter", delay)
+			return ctrl.Result{RequeueAfter: delay}, nil
+		}
+
 		// Create new pod first
-		newPod := r.createPodForMiniCloneSet(myCR, len(podList.Items))
+		newPod, err := r.createPodForMiniCloneSet(ctx, myCR, podList, desiredRevision)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
 		if err := r.Create(ctx, newPod); err != nil {
 			log.Error(err, "failed to create replacement pod", "pod", newPod.Name)
 			return ctrl.Result{}, err
 		}
+		podList.Items = append(podList.Items, *newPod)
 
 		log.Info("Created replacement pod for rolling update", "newPod", newPod.Name, "oldPod", podToUpdate.Name)
 
@@ -138,10 +347,14 @@ func (r *MiniCloneSetReconciler) handleRollingUpdate(ctx context.Context, myCR *
 
 	// Scale down if we have too many pods
 	if currentPods > desiredReplicas {
-		podsToDelete := currentPods - desiredReplicas
-		for i := 0; i < podsToDelete && i < len(podList.Items); i++ {
-			pod := &podList.Items[i]
+		pods := sortForScaleDown(myCR, podList.Items, desiredRevision)
+		for i := range podsToDelete(myCR, currentPods-desiredReplicas) {
+			pod := &pods[i]
 			if err := r.Delete(ctx, pod); err != nil {
+				if budgetDenied(err) {
+					log.Info("Disruption budget denied deleting pod, retrying later", "pod", pod.Name)
+					return ctrl.Result{RequeueAfter: budgetRequeue}, nil
+				}
 				log.Error(err, "failed to delete pod", "pod", pod.Name)
 				return ctrl.Result{}, err
 			}
@@ -150,24 +363,17 @@ func (r *MiniCloneSetReconciler) handleRollingUpdate(ctx context.Context, myCR *
 		return ctrl.Result{RequeueAfter: time.Second * 5}, nil
 	}
 
-	// Update status
-	myCR.Status.AvailableReplicas = readyPods
-	if err := r.Status().Update(ctx, myCR); err != nil {
-		log.Error(err, "failed to update MiniCloneSet status")
-		return ctrl.Result{}, err
-	}
-
 	return ctrl.Result{}, nil
 }
 
 // handleRecreateUpdate implements recreate update strategy
-func (r *MiniCloneSetReconciler) handleRecreateUpdate(ctx context.Context, myCR *appsexamplecomv1alpha1.MiniCloneSet, podList *corev1.PodList, desiredReplicas int, desiredImage string) (ctrl.Result, error) {
+func (r *MiniCloneSetReconciler) handleRecreateUpdate(ctx context.Context, myCR *appsexamplecomv1alpha1.MiniCloneSet, podList *corev1.PodList, desiredReplicas int, desiredRevision string) (ctrl.Result, error) {
 	log := logf.FromContext(ctx)
 
 	// Check if any pods need updating
 	needsUpdate := false
 	for _, pod := range podList.Items {
-		if !isPodUpToDate(&pod, desiredImage) {
+		if !isPodUpToDate(&pod, desiredRevision) {
 			needsUpdate = true
 			break
 		}
@@ -177,6 +383,10 @@ func (r *MiniCloneSetReconciler) handleRecreateUpdate(ctx context.Context, myCR
 		// Delete all existing pods first
 		for _, pod := range podList.Items {
 			if err := r.Delete(ctx, &pod); err != nil {
+				if budgetDenied(err) {
+					log.Info("Disruption budget denied deleting pod, retrying later", "pod", pod.Name)
+					return ctrl.Result{RequeueAfter: budgetRequeue}, nil
+				}
 				log.Error(err, "failed to delete pod during recreate", "pod", pod.Name)
 				return ctrl.Result{}, err
 			}
@@ -190,49 +400,229 @@ func (r *MiniCloneSetReconciler) handleRecreateUpdate(ctx context.Context, myCR
 	// Create new pods if needed
 	currentPods := len(podList.Items)
 	if currentPods < desiredReplicas {
-		for i := currentPods; i < desiredReplicas; i++ {
-			pod := r.createPodForMiniCloneSet(myCR, i)
+		for range podsToCreate(myCR, podList, desiredReplicas-currentPods) {
+			pod, err := r.createPodForMiniCloneSet(ctx, myCR, podList, desiredRevision)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
 			if err := r.Create(ctx, pod); err != nil {
 				log.Error(err, "failed to create pod", "pod", pod.Name)
 				return ctrl.Result{}, err
 			}
+			podList.Items = append(podList.Items, *pod)
 			log.Info("Created new pod", "pod", pod.Name)
 		}
 		return ctrl.Result{RequeueAfter: time.Second * 10}, nil
 	}
 
-	// Update status
-	readyPods := 0
-	for _, pod := range podList.Items {
-		if isPodReady(&pod) {
-			readyPods++
+	return ctrl.Result{}, nil
+}
+
+// handleScale only adds or removes pods to match desiredReplicas and leaves
+// outdated pods running. Pods are removed in sortForScaleDown order.
+func (r *MiniCloneSetReconciler) handleScale(ctx context.Context, myCR *appsexamplecomv1alpha1.MiniCloneSet, podList *corev1.PodList, desiredReplicas int, desiredRevision string) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	currentPods := len(podList.Items)
+	if currentPods < desiredReplicas {
+		for range podsToCreate(myCR, podList, desiredReplicas-currentPods) {
+			pod, err := r.createPodForMiniCloneSet(ctx, myCR, podList, desiredRevision)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			if err := r.Create(ctx, pod); err != nil {
+				log.Error(err, "failed to create pod", "pod", pod.Name)
+				return ctrl.Result{}, err
+			}
+			podList.Items = append(podList.Items, *pod)
+			log.Info("Created new pod", "pod", pod.Name)
 		}
+		return ctrl.Result{RequeueAfter: time.Second * 10}, nil
 	}
 
-	myCR.Status.AvailableReplicas = readyPods
-	if err := r.Status().Update(ctx, myCR); err != nil {
-		log.Error(err, "failed to update MiniCloneSet status")
-		return ctrl.Result{}, err
+	if currentPods > desiredReplicas {
+		pods := sortForScaleDown(myCR, podList.Items, desiredRevision)
+		for i := range podsToDelete(myCR, currentPods-desiredReplicas) {
+			if err := r.Delete(ctx, &pods[i]); err != nil {
+				if budgetDenied(err) {
+					log.Info("Disruption budget denied deleting pod, retrying later", "pod", pods[i].Name)
+					return ctrl.Result{RequeueAfter: budgetRequeue}, nil
+				}
+				log.Error(err, "failed to delete pod", "pod", pods[i].Name)
+				return ctrl.Result{}, client.IgnoreNotFound(err)
+			}
+			log.Info("Deleted excess pod", "pod", pods[i].Name)
+		}
+		return ctrl.Result{RequeueAfter: time.Second * 5}, nil
 	}
 
 	return ctrl.Result{}, nil
 }
 
+// deletionCost ranks pods for scale down: unready pods go first, then outdated ones
+func deletionCost(pod *corev1.Pod, desiredRevision string) int {
+	cost := 0
+	if isPodReady(pod) {
+		cost += 2
+	}
+	if isPodUpToDate(pod, desiredRevision) {
+		cost++
+	}
+	return cost
+}
+
+// handleBlueGreenUpdate implements blue/green update strategy
+func (r *MiniCloneSetReconciler) handleBlueGreenUpdate(ctx context.Context, myCR *appsexamplecomv1alpha1.MiniCloneSet, podList *corev1.PodList, desiredReplicas int, desiredRevision string) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	// Split pods into the new (green) set and the old (blue) set
+	updatedPods := []corev1.Pod{}
+	outdatedPods := []corev1.Pod{}
+	updatedReady := true
+	for _, pod := range podList.Items {
+		if !isPodUpToDate(&pod, desiredRevision) {
+			outdatedPods = append(outdatedPods, pod)
+			continue
+		}
+		updatedPods = append(updatedPods, pod)
+		if !isPodReady(&pod) {
+			updatedReady = false
+		}
+	}
+
+	// Bring up a full set of new pods next to the old ones
+	if len(updatedPods) < desiredReplicas {
+		for range podsToCreate(myCR, podList, desiredReplicas-len(updatedPods)) {
+			pod, err := r.createPodForMiniCloneSet(ctx, myCR, podList, desiredRevision)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			if err := r.Create(ctx, pod); err != nil {
+				log.Error(err, "failed to create pod", "pod", pod.Name)
+				return ctrl.Result{}, err
+			}
+			podList.Items = append(podList.Items, *pod)
+			log.Info("Created new pod for blue/green update", "pod", pod.Name)
+		}
+		return ctrl.Result{RequeueAfter: time.Second * 10}, nil
+	}
+
+	// Scale down the new set if we have too many pods
+	if len(updatedPods) > desiredReplicas {
+		excessPods := sortForScaleDown(myCR, updatedPods, desiredRevision)
+		for i := 0; i < len(updatedPods)-desiredReplicas; i++ {
+			if err := r.Delete(ctx, &excessPods[i]); err != nil {
+				if budgetDenied(err) {
+					log.Info("Disruption budget denied deleting pod, retrying later", "pod", excessPods[i].Name)
+					return ctrl.Result{RequeueAfter: budgetRequeue}, nil
+				}
+				log.Error(err, "failed to delete pod", "pod", excessPods[i].Name)
+				return ctrl.Result{}, client.IgnoreNotFound(err)
+			}
+			log.Info("Deleted excess pod", "pod", excessPods[i].Name)
+		}
+		return ctrl.Result{RequeueAfter: time.Second * 5}, nil
+	}
+
+	// Keep serving from the old set until every new pod is available
+	if !updatedReady {
+		return ctrl.Result{RequeueAfter: time.Second * 5}, nil
+	}
+
+	// Move the active label over to the new set. A status written before
+	// activeSwitchTime existed restarts the scale down delay.
+	if myCR.Status.ActiveRevision != desiredRevision || myCR.Status.ActiveSwitchTime == nil {
+		now := metav1.Now()
+		myCR.Status.ActiveRevision = desiredRevision
+		myCR.Status.ActiveSwitchTime = &now
+		log.Info("Switching active pods", "revision", desiredRevision)
+	}
+	for i := range updatedPods {
+		if err := r.setPodActive(ctx, &updatedPods[i], true); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	for i := range outdatedPods {
+		if err := r.setPodActive(ctx, &outdatedPods[i], false); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if len(outdatedPods) == 0 {
+		return ctrl.Result{}, nil
+	}
+
+	// Keep the old set around for a while so the switch can be reverted instantly
+	delay := blueGreenScaleDownDelay(myCR)
+	if remaining := time.Until(myCR.Status.ActiveSwitchTime.Add(delay)); remaining > 0 {
+		return ctrl.Result{RequeueAfter: remaining}, nil
+	}
+	for i := range outdatedPods {
+		if err := r.Delete(ctx, &outdatedPods[i]); err != nil {
+			if budgetDenied(err) {
+				log.Info("Disruption budget denied deleting pod, retrying later", "pod", outdatedPods[i].Name)
+				return ctrl.Result{RequeueAfter: budgetRequeue}, nil
+			}
+			log.Error(err, "failed to delete old pod after blue/green switch", "pod", outdatedPods[i].Name)
+			return ctrl.Result{}, client.IgnoreNotFound(err)
+		}
+		log.Info("Deleted old pod after blue/green switch", "pod", outdatedPods[i].Name)
+	}
+	return ctrl.Result{RequeueAfter: time.Second * 5}, nil
+}
+
+// setPodActive adds or removes the active label on a pod
+func (r *MiniCloneSetReconciler) setPodActive(ctx context.Context, pod *corev1.Pod, active bool) error {
+	if _, ok := pod.Labels[appsexamplecomv1alpha1.ActiveLabel]; ok == active {
+		return nil
+	}
+
+	patch := client.MergeFrom(pod.DeepCopy())
+	if active {
+		pod.Labels[appsexamplecomv1alpha1.ActiveLabel] = "true"
+	} else {
+		delete(pod.Labels, appsexamplecomv1alpha1.ActiveLabel)
+	}
+	if err := r.Patch(ctx, pod, patch); err != nil {
+		logf.FromContext(ctx).Error(err, "failed to update active label", "pod", pod.Name)
+		return client.IgnoreNotFound(err)
+	}
+	return nil
+}
+
+// blueGreenScaleDownDelay returns how long old pods are kept after a blue/green switch
+func blueGreenScaleDownDelay(myCR *appsexamplecomv1alpha1.MiniCloneSet) time.Duration {
+	if myCR.Spec.BlueGreen == nil {
+		return defaultScaleDownDelay
+	}
+	return time.Duration(myCR.Spec.BlueGreen.ScaleDownDelaySeconds) * time.Second
+}
+
 // createPodForMiniCloneSet creates a new pod based on the MiniCloneSet spec
-func (r *MiniCloneSetReconciler) createPodForMiniCloneSet(myCR *appsexamplecomv1alpha1.MiniCloneSet, index int) *corev1.Pod {
+// to join the pods in podList. It gets the template's pod metadata, is placed
+// in the first spread subset with room, gets the sidecars of every
+// MiniSidecarSet selecting the MiniCloneSet, learns its identity through
+// labels and environment variables and finally gets the instance overrides
+// selecting it.
+func (r *MiniCloneSetReconciler) createPodForMiniCloneSet(ctx context.Context, myCR *appsexamplecomv1alpha1.MiniCloneSet, podList *corev1.PodList, revision string) (*corev1.Pod, error) {
+	index := nextPodIndex(myCR.Name, podList)
 	pod := &corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      fmt.Sprintf("%s-%d", myCR.Name, index),
 			Namespace: myCR.Namespace,
 			Labels: map[string]string{
-				"app": myCR.Name,
+				"app":                                    myCR.Name,
+				appsv1.ControllerRevisionHashLabelKey:    revision,
+				appsexamplecomv1alpha1.MiniCloneSetLabel: myCR.Name,
 			},
 		},
 		Spec: corev1.PodSpec{
 			Containers: []corev1.Container{
 				{
-					Name:  "main",
-					Image: myCR.Spec.Image,
+					Name:      "main",
+					Image:     myCR.Spec.Image,
+					Resources: *myCR.Spec.Resources.DeepCopy(),
+					Env:       slices.Clone(myCR.Spec.Env),
 					Ports: []corev1.ContainerPort{
 						{
 							ContainerPort: 80,
@@ -244,9 +634,63 @@ func (r *MiniCloneSetReconciler) createPodForMiniCloneSet(myCR *appsexamplecomv1
 		},
 	}
 
+	updatePodMetadata(pod, nil, myCR.Spec.PodMetadata)
+	addPreDeleteLabels(myCR, pod)
+
+	if myCR.Spec.Mode == appsexamplecomv1alpha1.PerNodeMode {
+		node, err := r.pickNode(ctx, myCR, podList, revision)
+		if err != nil {
+			return nil, err
+		}
+		placeOnNode(pod, node)
+	} else {
+		subset, err := pickSubset(myCR, podList)
+		if err != nil {
+			return nil, err
+		}
+		if subset != nil {
+			placeInSubset(pod, subset)
+		} else if myCR.Spec.Spread != nil {
+			logf.FromContext(ctx).Info("Every spread subset is full, creating pod without placement", "pod", pod.Name)
+		}
+	}
+
+	var sidecarSets appsexamplecomv1alpha1.MiniSidecarSetList
+	if err := r.List(ctx, &sidecarSets); err != nil {
+		return nil, err
+	}
+	sidecars, err := matchingSidecars(sidecarSets.Items, myCR)
+	if err != nil {
+		return nil, err
+	}
+	for _, sidecar := range sidecars {
+		if slices.ContainsFunc(pod.Spec.Containers, func(c corev1.Container) bool { return c.Name == sidecar.Name }) {
+			logf.FromContext(ctx).Info("Skipping sidecar with a conflicting container name", "container", sidecar.Name)
+			continue
+		}
+		pod.Spec.Containers = append(pod.Spec.Containers, sidecar)
+	}
+	injectIdentity(myCR, pod, index)
+	if err := applyInstanceOverrides(myCR, pod, index); err != nil {
+		return nil, err
+	}
+
 	// Set owner reference
 	ctrl.SetControllerReference(myCR, pod, r.Scheme)
-	return pod
+	return pod, nil
+}
+
+// nextPodIndex returns the lowest index that no pod in the list is named after
+func nextPodIndex(name string, podList *corev1.PodList) int {
+	used := make(map[string]bool, len(podList.Items))
+	for _, pod := range podList.Items {
+		used[pod.Name] = true
+	}
+	for i := 0; ; i++ {
+		if !used[fmt.Sprintf("%s-%d", name, i)] {
+			return i
+		}
+	}
 }
 
 // isPodReady checks if a pod is ready
@@ -259,20 +703,20 @@ func isPodReady(pod *corev1.Pod) bool {
 	return false
 }
 
-// isPodUpToDate checks if a pod is using the desired image
-func isPodUpToDate(pod *corev1.Pod, desiredImage string) bool {
-	for _, container := range pod.Spec.Containers {
-		if container.Name == "main" && container.Image != desiredImage {
-			return false
-		}
-	}
-	return true
+// isPodUpToDate checks if a pod was created from the desired revision
+func isPodUpToDate(pod *corev1.Pod, desiredRevision string) bool {
+	return pod.Labels[appsv1.ControllerRevisionHashLabelKey] == desiredRevision
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *MiniCloneSetReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&appsexamplecomv1alpha1.MiniCloneSet{}).
+		Owns(&corev1.Pod{}).
+		Owns(&appsv1.ControllerRevision{}).
+		Owns(&corev1.Service{}).
+		Owns(&policyv1.PodDisruptionBudget{}).
+		Watches(&corev1.Node{}, handler.EnqueueRequestsFromMapFunc(r.perNodeMiniCloneSets), builder.WithPredicates(nodeChanged)).
 		Named("minicloneset").
 		Complete(r)
 }
@@ -18,16 +18,39 @@ package controller
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/robfig/cron/v3"
+	admissionv1 "k8s.io/api/admission/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	appsexamplecomv1alpha1 "k8s.openkruise.com/v1/api/v1alpha1"
+	webhookv1 "k8s.openkruise.com/v1/internal/webhook/v1"
 )
 
 var _ = Describe("MiniCloneSet Controller", func() {
@@ -73,8 +96,9 @@ var _ = Describe("MiniCloneSet Controller", func() {
 		It("should successfully reconcile the resource", func() {
 			By("Reconciling the created resource")
 			controllerReconciler := &MiniCloneSetReconciler{
-				Client: k8sClient,
-				Scheme: k8sClient.Scheme(),
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: record.NewFakeRecorder(10),
 			}
 
 			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
@@ -85,4 +109,1401 @@ var _ = Describe("MiniCloneSet Controller", func() {
 			// Example: If you expect a certain status condition after reconciliation, verify it here.
 		})
 	})
+
+	Context("When a rollout stops making progress", func() {
+		const resourceName = "stuck-resource"
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: "default",
+		}
+
+		BeforeEach(func() {
+			By("creating a MiniCloneSet with a progress deadline")
+			deadline := int32(1)
+			resource := &appsexamplecomv1alpha1.MiniCloneSet{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: "default",
+				},
+				Spec: appsexamplecomv1alpha1.MiniCloneSetSpec{
+					Replicas:                1,
+					Image:                   "nginx:does-not-exist",
+					UpdateStrategy:          appsexamplecomv1alpha1.RollingUpdateStrategyType,
+					ProgressDeadlineSeconds: &deadline,
+				},
+			}
+			Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			resource := &appsexamplecomv1alpha1.MiniCloneSet{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+		})
+
+		It("should report ProgressDeadlineExceeded", func() {
+			recorder := record.NewFakeRecorder(10)
+			controllerReconciler := &MiniCloneSetReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: recorder,
+			}
+
+			By("creating the first pod, which never becomes ready")
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("moving the last progress time past the deadline")
+			resource := &appsexamplecomv1alpha1.MiniCloneSet{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			past := metav1.NewTime(time.Now().Add(-time.Minute))
+			resource.Status.LastProgressTime = &past
+			Expect(k8sClient.Status().Update(ctx, resource)).To(Succeed())
+
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			cond := meta.FindStatusCondition(resource.Status.Conditions, appsexamplecomv1alpha1.ProgressingCondition)
+			Expect(cond).NotTo(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Reason).To(Equal(appsexamplecomv1alpha1.ProgressDeadlineExceededReason))
+			Expect(recorder.Events).To(Receive(ContainSubstring(appsexamplecomv1alpha1.ProgressDeadlineExceededReason)))
+		})
+	})
+})
+
+var _ = Describe("Failed pod detection", func() {
+	now := time.Now()
+	window := time.Minute
+
+	newPod := func(age time.Duration, waitingReason string, ready bool) corev1.Pod {
+		pod := corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				CreationTimestamp: metav1.NewTime(now.Add(-age)),
+				Labels:            map[string]string{appsv1.ControllerRevisionHashLabelKey: "new"},
+			},
+		}
+		if waitingReason != "" {
+			pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+				Name:  "main",
+				State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: waitingReason}},
+			}}
+		}
+		if ready {
+			pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}
+		}
+		return pod
+	}
+
+	It("should count crash-looping and never-ready pods of the revision", func() {
+		podList := &corev1.PodList{Items: []corev1.Pod{
+			newPod(time.Second, "CrashLoopBackOff", false),
+			newPod(2*time.Minute, "", false),
+			newPod(time.Second, "", false),
+			newPod(2*time.Minute, "", true),
+		}}
+		Expect(countFailedPods(podList, "new", window, now)).To(Equal(2))
+		Expect(countFailedPods(podList, "old", window, now)).To(BeZero())
+	})
+})
+
+var _ = Describe("Revision history", func() {
+	It("should resolve a hash collision with another template", func() {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(appsexamplecomv1alpha1.AddToScheme(scheme)).To(Succeed())
+		myCR := &appsexamplecomv1alpha1.MiniCloneSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", UID: "web"},
+			Spec:       appsexamplecomv1alpha1.MiniCloneSetSpec{Image: "nginx:1.20"},
+		}
+		patch, err := getPatch(myCR)
+		Expect(err).NotTo(HaveOccurred())
+		hash := computeHash(patch, nil)
+		colliding := &appsv1.ControllerRevision{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            revisionName(myCR, hash),
+				Namespace:       "default",
+				Labels:          map[string]string{"app": "web", appsv1.ControllerRevisionHashLabelKey: hash},
+				OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(myCR, appsexamplecomv1alpha1.GroupVersion.WithKind("MiniCloneSet"))},
+			},
+			Data:     runtime.RawExtension{Raw: []byte(`{"spec":{"image":"nginx:1.21"}}`)},
+			Revision: 1,
+		}
+		r := &MiniCloneSetReconciler{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(colliding).Build(), Scheme: scheme}
+
+		updateRevision, err := r.syncRevision(ctx, myCR)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(updateRevision).NotTo(Equal(hash))
+		Expect(myCR.Status.CollisionCount).To(HaveValue(Equal(int32(1))))
+		revision := &appsv1.ControllerRevision{}
+		Expect(r.Get(ctx, types.NamespacedName{Namespace: "default", Name: revisionName(myCR, updateRevision)}, revision)).To(Succeed())
+		Expect(sameTemplate(revision.Data.Raw, patch)).To(BeTrue())
+
+		By("reusing the revision on the next reconcile")
+		Expect(r.syncRevision(ctx, myCR)).To(Equal(updateRevision))
+		Expect(myCR.Status.CollisionCount).To(HaveValue(Equal(int32(1))))
+	})
+})
+
+var _ = Describe("Canary steps", func() {
+	It("should hold the rollout at a paused step", func() {
+		duration := int32(60)
+		myCR := &appsexamplecomv1alpha1.MiniCloneSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "canary", Namespace: "default"},
+			Spec: appsexamplecomv1alpha1.MiniCloneSetSpec{
+				Replicas: 4,
+				Image:    "nginx:1.21",
+				Canary: &appsexamplecomv1alpha1.CanaryStrategy{Steps: []appsexamplecomv1alpha1.CanaryStep{
+					{Replicas: intstr.FromInt32(1)},
+					{Replicas: intstr.FromString("50%"), Pause: &appsexamplecomv1alpha1.CanaryPause{DurationSeconds: &duration}},
+				}},
+			},
+			Status: appsexamplecomv1alpha1.MiniCloneSetStatus{CurrentRevision: "old", UpdateRevision: "new"},
+		}
+		readyPod := func(revision string) corev1.Pod {
+			return corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{appsv1.ControllerRevisionHashLabelKey: revision}},
+				Status:     corev1.PodStatus{Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}},
+			}
+		}
+		podList := &corev1.PodList{Items: []corev1.Pod{readyPod("new"), readyPod("new"), readyPod("old"), readyPod("old")}}
+
+		controllerReconciler := &MiniCloneSetReconciler{}
+		maxUpdated, result, err := controllerReconciler.syncCanary(context.Background(), myCR, podList, myCR.Spec.Replicas)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(maxUpdated).To(Equal(2))
+		Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+		Expect(*myCR.Status.CurrentStepIndex).To(Equal(int32(1)))
+		Expect(myCR.Status.CurrentStepState).To(Equal(appsexamplecomv1alpha1.CanaryStepStatePaused))
+	})
+})
+
+var _ = Describe("BlueGreen update", func() {
+	var (
+		r     *MiniCloneSetReconciler
+		myCR  *appsexamplecomv1alpha1.MiniCloneSet
+		sync  func(revision string) ctrl.Result
+		pods  func(revision string) []corev1.Pod
+		ready func(revision string)
+	)
+
+	BeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(appsexamplecomv1alpha1.AddToScheme(scheme)).To(Succeed())
+		myCR = &appsexamplecomv1alpha1.MiniCloneSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", UID: "web"},
+			Spec: appsexamplecomv1alpha1.MiniCloneSetSpec{
+				Replicas:       2,
+				Image:          "nginx:1.21",
+				UpdateStrategy: appsexamplecomv1alpha1.BlueGreenStrategyType,
+				BlueGreen:      &appsexamplecomv1alpha1.BlueGreenStrategy{ScaleDownDelaySeconds: 60},
+			},
+			Status: appsexamplecomv1alpha1.MiniCloneSetStatus{ActiveRevision: "old"},
+		}
+		builder := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&corev1.Pod{})
+		for i := range 2 {
+			builder = builder.WithObjects(&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      fmt.Sprintf("web-%d", i),
+					Namespace: "default",
+					Labels: map[string]string{
+						"app":                                 "web",
+						appsv1.ControllerRevisionHashLabelKey: "old",
+						appsexamplecomv1alpha1.ActiveLabel:    "true",
+					},
+					OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(myCR, appsexamplecomv1alpha1.GroupVersion.WithKind("MiniCloneSet"))},
+				},
+				Status: corev1.PodStatus{Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}},
+			})
+		}
+		r = &MiniCloneSetReconciler{Client: builder.Build(), Scheme: scheme}
+
+		sync = func(revision string) ctrl.Result {
+			podList, _, err := r.listPods(ctx, myCR)
+			Expect(err).NotTo(HaveOccurred())
+			result, err := r.handleBlueGreenUpdate(ctx, myCR, podList, 2, revision)
+			Expect(err).NotTo(HaveOccurred())
+			return result
+		}
+		pods = func(revision string) []corev1.Pod {
+			podList, _, err := r.listPods(ctx, myCR)
+			Expect(err).NotTo(HaveOccurred())
+			var matching []corev1.Pod
+			for _, pod := range podList.Items {
+				if isPodUpToDate(&pod, revision) {
+					matching = append(matching, pod)
+				}
+			}
+			return matching
+		}
+		ready = func(revision string) {
+			for _, pod := range pods(revision) {
+				pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}
+				Expect(r.Status().Update(ctx, &pod)).To(Succeed())
+			}
+		}
+	})
+
+	isActive := func(pod corev1.Pod) bool {
+		_, ok := pod.Labels[appsexamplecomv1alpha1.ActiveLabel]
+		return ok
+	}
+
+	It("should switch to a full new set once all of it is ready and keep the old set for the delay", func() {
+		sync("new")
+		Expect(pods("new")).To(HaveLen(2))
+		Expect(pods("old")).To(HaveLen(2))
+
+		By("serving from the old set while the new one is not ready")
+		sync("new")
+		Expect(myCR.Status.ActiveRevision).To(Equal("old"))
+		Expect(pods("new")).NotTo(ContainElement(Satisfy(isActive)))
+		Expect(pods("old")).To(HaveEach(Satisfy(isActive)))
+
+		By("moving the active label once every new pod is ready")
+		ready("new")
+		result := sync("new")
+		Expect(myCR.Status.ActiveRevision).To(Equal("new"))
+		Expect(myCR.Status.ActiveSwitchTime).NotTo(BeNil())
+		Expect(pods("new")).To(HaveEach(Satisfy(isActive)))
+		Expect(pods("old")).NotTo(ContainElement(Satisfy(isActive)))
+		Expect(pods("old")).To(HaveLen(2))
+		Expect(result.RequeueAfter).To(BeNumerically("~", time.Minute, time.Second))
+
+		By("deleting the old set once the scale down delay has passed")
+		myCR.Status.ActiveSwitchTime = &metav1.Time{Time: time.Now().Add(-2 * time.Minute)}
+		sync("new")
+		Expect(pods("old")).To(BeEmpty())
+		Expect(pods("new")).To(HaveLen(2))
+	})
+
+	It("should switch straight back while the old set still exists", func() {
+		sync("new")
+		ready("new")
+		sync("new")
+		Expect(myCR.Status.ActiveRevision).To(Equal("new"))
+
+		sync("old")
+		Expect(myCR.Status.ActiveRevision).To(Equal("old"))
+		Expect(pods("old")).To(HaveEach(Satisfy(isActive)))
+		Expect(pods("new")).NotTo(ContainElement(Satisfy(isActive)))
+		Expect(pods("new")).To(HaveLen(2))
+	})
+
+	It("should restart the scale down delay when the switch time is missing", func() {
+		sync("new")
+		ready("new")
+		myCR.Status.ActiveRevision = "new"
+
+		Expect(func() { sync("new") }).NotTo(Panic())
+		Expect(myCR.Status.ActiveSwitchTime).NotTo(BeNil())
+		Expect(pods("old")).To(HaveLen(2))
+	})
+})
+
+var _ = Describe("Rollout approval", func() {
+	It("should record the field manager that set the annotation", func() {
+		approvedAt := metav1.NewTime(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC))
+		myCR := &appsexamplecomv1alpha1.MiniCloneSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{appsexamplecomv1alpha1.ApproveRevisionAnnotation: "new"},
+				ManagedFields: []metav1.ManagedFieldsEntry{
+					{
+						Manager:  "kubectl-edit",
+						Time:     &approvedAt,
+						FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:image":{}}}`)},
+					},
+					{
+						Manager: "kubectl-annotate",
+						Time:    &approvedAt,
+						FieldsV1: &metav1.FieldsV1{Raw: []byte(
+							`{"f:metadata":{"f:annotations":{".":{},"f:` + appsexamplecomv1alpha1.ApproveRevisionAnnotation + `":{}}}}`)},
+					},
+				},
+			},
+			Spec: appsexamplecomv1alpha1.MiniCloneSetSpec{RequireApproval: true},
+			Status: appsexamplecomv1alpha1.MiniCloneSetStatus{
+				CurrentRevision: "old",
+				UpdateRevision:  "new",
+			},
+		}
+		Expect(awaitingApproval(myCR)).To(BeTrue())
+
+		controllerReconciler := &MiniCloneSetReconciler{Recorder: record.NewFakeRecorder(10)}
+		Expect(controllerReconciler.syncApproval(myCR)).To(BeTrue())
+		Expect(myCR.Status.LastApproval).NotTo(BeNil())
+		Expect(myCR.Status.LastApproval.ApprovedBy).To(BeEmpty())
+		Expect(myCR.Status.LastApproval.FieldManager).To(Equal("kubectl-annotate"))
+		Expect(myCR.Status.LastApproval.ApprovedAt).To(Equal(approvedAt))
+		Expect(awaitingApproval(myCR)).To(BeFalse())
+
+		By("recording the claimed approver next to the field manager")
+		myCR.Annotations[appsexamplecomv1alpha1.ApprovedByAnnotation] = "alice"
+		myCR.Status.LastApproval = nil
+		Expect(controllerReconciler.syncApproval(myCR)).To(BeTrue())
+		Expect(myCR.Status.LastApproval.ApprovedBy).To(Equal("alice"))
+		Expect(myCR.Status.LastApproval.FieldManager).To(Equal("kubectl-annotate"))
+	})
+})
+
+var _ = Describe("Scaling while awaiting approval", func() {
+	It("should keep scaling with pods of the current revision", func() {
+		ctx := context.Background()
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(appsexamplecomv1alpha1.AddToScheme(scheme)).To(Succeed())
+		myCR := &appsexamplecomv1alpha1.MiniCloneSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", UID: "web"},
+			Spec: appsexamplecomv1alpha1.MiniCloneSetSpec{
+				Replicas:        2,
+				Image:           "nginx:1.20",
+				UpdateStrategy:  appsexamplecomv1alpha1.RollingUpdateStrategyType,
+				RequireApproval: true,
+			},
+		}
+		oldPatch, err := getPatch(myCR)
+		Expect(err).NotTo(HaveOccurred())
+		oldRevision := computeHash(oldPatch, nil)
+		myCR.Spec.Image = "nginx:1.21"
+		myCR.Status.CurrentRevision = oldRevision
+		revision := &appsv1.ControllerRevision{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            revisionName(myCR, oldRevision),
+				Namespace:       "default",
+				Labels:          map[string]string{"app": "web", appsv1.ControllerRevisionHashLabelKey: oldRevision},
+				OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(myCR, appsexamplecomv1alpha1.GroupVersion.WithKind("MiniCloneSet"))},
+			},
+			Data:     runtime.RawExtension{Raw: oldPatch},
+			Revision: 1,
+		}
+		r := &MiniCloneSetReconciler{
+			Client:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(myCR, revision).WithStatusSubresource(myCR).Build(),
+			Scheme:   scheme,
+			Recorder: record.NewFakeRecorder(10),
+		}
+
+		_, err = r.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "web"}})
+		Expect(err).NotTo(HaveOccurred())
+		var pods corev1.PodList
+		Expect(r.List(ctx, &pods)).To(Succeed())
+		Expect(pods.Items).To(HaveLen(2))
+		for _, pod := range pods.Items {
+			Expect(pod.Spec.Containers[0].Image).To(Equal("nginx:1.20"))
+			Expect(pod.Labels).To(HaveKeyWithValue(appsv1.ControllerRevisionHashLabelKey, oldRevision))
+		}
+	})
+})
+
+var _ = Describe("Update windows", func() {
+	tz := "America/New_York"
+	windows := []appsexamplecomv1alpha1.UpdateWindow{{
+		Schedule:        "0 22 * * *",
+		DurationSeconds: 2 * 60 * 60,
+		TimeZone:        &tz,
+	}}
+	location, _ := time.LoadLocation(tz)
+
+	It("should be open inside a window", func() {
+		open, _, err := updateWindowOpen(windows, time.Date(2025, 3, 4, 23, 30, 0, 0, location))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(open).To(BeTrue())
+	})
+
+	It("should report the next window start when closed", func() {
+		open, next, err := updateWindowOpen(windows, time.Date(2025, 3, 4, 12, 0, 0, 0, location))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(open).To(BeFalse())
+		Expect(next.Equal(time.Date(2025, 3, 4, 22, 0, 0, 0, location))).To(BeTrue())
+	})
+})
+
+var _ = Describe("Pod update rate limit", func() {
+	It("should space pod updates out over the minute", func() {
+		perMinute := int32(2)
+		myCR := &appsexamplecomv1alpha1.MiniCloneSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "limited", Namespace: "default"},
+			Spec:       appsexamplecomv1alpha1.MiniCloneSetSpec{MaxPodUpdatesPerMinute: &perMinute},
+		}
+
+		var limiters updateRateLimiters
+		Expect(limiters.reserve(myCR)).To(BeZero())
+		Expect(limiters.reserve(myCR)).To(BeNumerically("~", 30*time.Second, time.Second))
+
+		myCR.Spec.MaxPodUpdatesPerMinute = nil
+		Expect(limiters.reserve(myCR)).To(BeZero())
+	})
+
+	It("should hold the rollout while waiting for the rate limit", func() {
+		perMinute := int32(1)
+		myCR := &appsexamplecomv1alpha1.MiniCloneSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "limited", Namespace: "default"},
+			Spec:       appsexamplecomv1alpha1.MiniCloneSetSpec{MaxPodUpdatesPerMinute: &perMinute},
+			Status:     appsexamplecomv1alpha1.MiniCloneSetStatus{CurrentRevision: "old", UpdateRevision: "new"},
+		}
+
+		r := &MiniCloneSetReconciler{}
+		Expect(r.reservePodUpdate(myCR)).To(BeZero())
+		Expect(myCR.Status.NextPodUpdateTime).To(BeNil())
+		Expect(r.reservePodUpdate(myCR)).To(BeNumerically(">", 0))
+		Expect(myCR.Status.NextPodUpdateTime).NotTo(BeNil())
+		reason, _ := rolloutHold(myCR)
+		Expect(reason).To(Equal(appsexamplecomv1alpha1.RateLimitedReason))
+	})
+})
+
+var _ = Describe("Scale schedule", func() {
+	newMiniCloneSet := func() *appsexamplecomv1alpha1.MiniCloneSet {
+		return &appsexamplecomv1alpha1.MiniCloneSet{
+			Spec: appsexamplecomv1alpha1.MiniCloneSetSpec{
+				Replicas: 2,
+				ScaleSchedule: []appsexamplecomv1alpha1.ScheduledScale{
+					{Schedule: "0 8 * * *", Replicas: 10},
+					{Schedule: "0 20 * * *", Replicas: 3},
+				},
+			},
+		}
+	}
+
+	It("should use the replicas of the entry that fired last", func() {
+		r := &MiniCloneSetReconciler{Recorder: record.NewFakeRecorder(10)}
+		myCR := newMiniCloneSet()
+		replicas, untilNext, err := r.syncScaleSchedule(myCR, time.Date(2025, 3, 4, 12, 0, 0, 0, time.UTC))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(replicas).To(Equal(10))
+		Expect(untilNext).To(Equal(8 * time.Hour))
+		Expect(myCR.Status.ActiveScaleSchedule.Index).To(Equal(0))
+	})
+
+	It("should let a manual change of replicas win until the next entry fires", func() {
+		r := &MiniCloneSetReconciler{Recorder: record.NewFakeRecorder(10)}
+		myCR := newMiniCloneSet()
+		_, _, err := r.syncScaleSchedule(myCR, time.Date(2025, 3, 4, 12, 0, 0, 0, time.UTC))
+		Expect(err).NotTo(HaveOccurred())
+
+		myCR.Spec.Replicas = 5
+		replicas, _, err := r.syncScaleSchedule(myCR, time.Date(2025, 3, 4, 13, 0, 0, 0, time.UTC))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(replicas).To(Equal(5))
+
+		replicas, _, err = r.syncScaleSchedule(myCR, time.Date(2025, 3, 4, 21, 0, 0, 0, time.UTC))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(replicas).To(Equal(3))
+	})
+
+	It("should find the last firing without stepping through the whole lookback", func() {
+		schedule, _, err := parseSchedule("* * * * *", nil)
+		Expect(err).NotTo(HaveOccurred())
+		counting := &countingSchedule{Schedule: schedule}
+		now := time.Date(2025, 3, 4, 12, 0, 30, 0, time.UTC)
+		Expect(lastFiring(counting, now.Add(-scaleScheduleLookback), now)).To(Equal(time.Date(2025, 3, 4, 12, 0, 0, 0, time.UTC)))
+		Expect(counting.calls).To(BeNumerically("<", 10))
+
+		schedule, _, err = parseSchedule("0 8 1 1 *", nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lastFiring(schedule, now.Add(-scaleScheduleLookback), now).IsZero()).To(BeTrue())
+	})
+})
+
+// countingSchedule counts how often the next firing of a schedule is computed
+type countingSchedule struct {
+	cron.Schedule
+	calls int
+}
+
+func (s *countingSchedule) Next(t time.Time) time.Time {
+	s.calls++
+	return s.Schedule.Next(t)
+}
+
+var _ = Describe("Hibernation", func() {
+	It("should remember the replicas and revision and restore them on wake up", func() {
+		r := &MiniCloneSetReconciler{Recorder: record.NewFakeRecorder(10)}
+		myCR := &appsexamplecomv1alpha1.MiniCloneSet{
+			Spec: appsexamplecomv1alpha1.MiniCloneSetSpec{Replicas: 3, Hibernate: true},
+			Status: appsexamplecomv1alpha1.MiniCloneSetStatus{
+				CurrentRevision: "old",
+				UpdateRevision:  "new",
+			},
+		}
+
+		hibernating, _, err := r.syncHibernation(ctx, myCR, &corev1.PodList{}, 0, 3)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(hibernating).To(BeTrue())
+		Expect(myCR.Status.DesiredReplicas).To(Equal(0))
+		Expect(myCR.Status.Hibernation.Replicas).To(Equal(3))
+		Expect(myCR.Status.Hibernation.Revision).To(Equal("old"))
+		reason, _ := rolloutHold(myCR)
+		Expect(reason).To(Equal(appsexamplecomv1alpha1.HibernatedReason))
+
+		myCR.Spec.Hibernate = false
+		podList := &corev1.PodList{Items: make([]corev1.Pod, 3)}
+		hibernating, _, err = r.syncHibernation(ctx, myCR, podList, 0, 3)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(hibernating).To(BeFalse())
+		Expect(myCR.Status.Hibernation).To(BeNil())
+	})
+})
+
+var _ = Describe("Workload spread", func() {
+	maxZoneA := intstr.FromString("60%")
+	myCR := &appsexamplecomv1alpha1.MiniCloneSet{
+		Spec: appsexamplecomv1alpha1.MiniCloneSetSpec{
+			Spread: &appsexamplecomv1alpha1.SpreadPolicy{Subsets: []appsexamplecomv1alpha1.SpreadSubset{
+				{Name: "zone-a", MaxReplicas: &maxZoneA},
+				{Name: "zone-b"},
+			}},
+		},
+		Status: appsexamplecomv1alpha1.MiniCloneSetStatus{DesiredReplicas: 5},
+	}
+	podsIn := func(subsets ...string) []corev1.Pod {
+		pods := []corev1.Pod{}
+		for _, subset := range subsets {
+			pods = append(pods, corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{appsexamplecomv1alpha1.SubsetLabel: subset},
+			}})
+		}
+		return pods
+	}
+
+	It("should place pods in the first subset with room", func() {
+		subset, err := pickSubset(myCR, &corev1.PodList{Items: podsIn("zone-a", "zone-a")})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(subset.Name).To(Equal("zone-a"))
+
+		subset, err = pickSubset(myCR, &corev1.PodList{Items: podsIn("zone-a", "zone-a", "zone-a")})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(subset.Name).To(Equal("zone-b"))
+	})
+
+	It("should scale down subsets above their limit first, then in reverse priority", func() {
+		pods := sortForScaleDown(myCR, podsIn("zone-a", "zone-a", "zone-a", "zone-a", "zone-a", "zone-b"), "")
+		subsets := []string{}
+		for _, pod := range pods {
+			subsets = append(subsets, pod.Labels[appsexamplecomv1alpha1.SubsetLabel])
+		}
+		Expect(subsets).To(Equal([]string{"zone-a", "zone-a", "zone-b", "zone-a", "zone-a", "zone-a"}))
+	})
+})
+
+var _ = Describe("PerNode mode", func() {
+	node := func(name string, labels map[string]string) *corev1.Node {
+		return &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+	}
+	podOn := func(name, node, revision string) corev1.Pod {
+		return corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels: map[string]string{
+				appsexamplecomv1alpha1.NodeLabel:      node,
+				appsv1.ControllerRevisionHashLabelKey: revision,
+			},
+		}}
+	}
+
+	It("should run one pod per matching node and drop pods of other nodes", func() {
+		agent := map[string]string{"role": "agent"}
+		pods := []corev1.Pod{podOn("agent-0", "node-a", "v1"), podOn("agent-1", "node-c", "v1")}
+		r := &MiniCloneSetReconciler{Client: fake.NewClientBuilder().WithObjects(
+			node("node-a", agent), node("node-b", agent), node("node-c", nil), &pods[0], &pods[1],
+		).Build()}
+		myCR := &appsexamplecomv1alpha1.MiniCloneSet{Spec: appsexamplecomv1alpha1.MiniCloneSetSpec{
+			Mode:         appsexamplecomv1alpha1.PerNodeMode,
+			NodeSelector: agent,
+		}}
+
+		podList := &corev1.PodList{Items: pods}
+		desiredReplicas, err := r.syncNodes(ctx, myCR, podList)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(desiredReplicas).To(Equal(2))
+		Expect(podList.Items).To(HaveLen(1))
+		Expect(podList.Items[0].Name).To(Equal("agent-0"))
+
+		nodeName, err := r.pickNode(ctx, myCR, podList, "v1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(nodeName).To(Equal("node-b"))
+
+		// A new pod of a new revision never lands next to an outdated pod
+		// while another node has no pod at all
+		nodeName, err = r.pickNode(ctx, myCR, podList, "v2")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(nodeName).To(Equal("node-b"))
+
+		// Once every node runs a pod, a new revision replaces the pod of the first node first
+		podList.Items = append(podList.Items, podOn("agent-2", "node-b", "v1"))
+		nodeName, err = r.pickNode(ctx, myCR, podList, "v2")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(nodeName).To(Equal("node-a"))
+
+		podList.Items = append(podList.Items, podOn("agent-3", "node-a", "v2"))
+		nodeName, err = r.pickNode(ctx, myCR, podList, "v2")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(nodeName).To(Equal("node-b"))
+	})
+
+	It("should only react to node changes that affect placement", func() {
+		oldNode := node("node-a", map[string]string{"role": "agent"})
+		heartbeat := oldNode.DeepCopy()
+		heartbeat.Status.Conditions = []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}}
+		Expect(nodeChanged.Update(event.UpdateEvent{ObjectOld: oldNode, ObjectNew: heartbeat})).To(BeFalse())
+
+		relabeled := oldNode.DeepCopy()
+		relabeled.Labels["role"] = "server"
+		Expect(nodeChanged.Update(event.UpdateEvent{ObjectOld: oldNode, ObjectNew: relabeled})).To(BeTrue())
+
+		cordoned := oldNode.DeepCopy()
+		cordoned.Spec.Unschedulable = true
+		Expect(nodeChanged.Update(event.UpdateEvent{ObjectOld: oldNode, ObjectNew: cordoned})).To(BeTrue())
+	})
+})
+
+var _ = Describe("Stable identity", func() {
+	readyPod := func(name, revision string) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+				Labels:    map[string]string{appsv1.ControllerRevisionHashLabelKey: revision},
+			},
+			Status: corev1.PodStatus{Conditions: []corev1.PodCondition{
+				{Type: corev1.PodReady, Status: corev1.ConditionTrue},
+			}},
+		}
+	}
+	newMiniCloneSet := func() *appsexamplecomv1alpha1.MiniCloneSet {
+		myCR := &appsexamplecomv1alpha1.MiniCloneSet{Spec: appsexamplecomv1alpha1.MiniCloneSetSpec{
+			StableIdentity:      true,
+			PodManagementPolicy: appsexamplecomv1alpha1.OrderedReadyPodManagement,
+		}}
+		myCR.Name = "db"
+		return myCR
+	}
+
+	It("should create one pod at a time once the others are ready", func() {
+		myCR := newMiniCloneSet()
+		podList := &corev1.PodList{Items: []corev1.Pod{readyPod("db-0", "v1")}}
+		Expect(podsToCreate(myCR, podList, 2)).To(Equal(1))
+
+		podList.Items[0].Status.Conditions = nil
+		Expect(podsToCreate(myCR, podList, 2)).To(Equal(0))
+	})
+
+	It("should scale down from the highest ordinal", func() {
+		pods := sortForScaleDown(newMiniCloneSet(), []corev1.Pod{
+			readyPod("db-2", "v1"), readyPod("db-10", "v1"), readyPod("db-0", "v1"),
+		}, "v1")
+		Expect([]string{pods[0].Name, pods[1].Name, pods[2].Name}).To(Equal([]string{"db-10", "db-2", "db-0"}))
+	})
+
+	It("should update the outdated pod with the highest ordinal first", func() {
+		pods := []corev1.Pod{readyPod("db-0", "v1"), readyPod("db-1", "v1"), readyPod("db-2", "v2")}
+		fakeClient := fake.NewClientBuilder().WithObjects(&pods[0], &pods[1], &pods[2]).Build()
+		r := &MiniCloneSetReconciler{Client: fakeClient}
+
+		_, err := r.handleOrdinalUpdate(ctx, newMiniCloneSet(), &corev1.PodList{Items: pods}, 3, "v2", 3)
+		Expect(err).NotTo(HaveOccurred())
+
+		var remaining corev1.PodList
+		Expect(fakeClient.List(ctx, &remaining)).To(Succeed())
+		names := []string{}
+		for _, pod := range remaining.Items {
+			names = append(names, pod.Name)
+		}
+		Expect(names).To(ConsistOf("db-0", "db-2"))
+	})
+})
+
+var _ = Describe("Pod identity", func() {
+	It("should label pods and expose their identity to every container", func() {
+		myCR := &appsexamplecomv1alpha1.MiniCloneSet{Status: appsexamplecomv1alpha1.MiniCloneSetStatus{DesiredReplicas: 8}}
+		myCR.Name = "consumer"
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{}},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{
+				{Name: "main", Env: []corev1.EnvVar{{Name: "LOG_LEVEL", Value: "debug"}}},
+				{Name: "log-shipper"},
+			}},
+		}
+		injectIdentity(myCR, pod, 3)
+
+		Expect(pod.Labels).To(HaveKeyWithValue(appsexamplecomv1alpha1.OrdinalLabel, "3"))
+		Expect(pod.Labels).To(HaveKey(appsexamplecomv1alpha1.InstanceIDLabel))
+		for _, container := range pod.Spec.Containers {
+			Expect(container.Env).To(ContainElements(
+				corev1.EnvVar{Name: nameEnvVar, Value: "consumer"},
+				corev1.EnvVar{Name: instanceIDEnvVar, Value: pod.Labels[appsexamplecomv1alpha1.InstanceIDLabel]},
+				corev1.EnvVar{Name: ordinalEnvVar, Value: "3"},
+				corev1.EnvVar{Name: replicasEnvVar, Value: "8"},
+			))
+			Expect(container.Env).To(ContainElement(HaveField("ValueFrom.FieldRef.FieldPath", "metadata.labels['controller-revision-hash']")))
+		}
+		Expect(pod.Spec.Containers[0].Env[0].Name).To(Equal("LOG_LEVEL"))
+	})
+})
+
+var _ = Describe("Instance overrides", func() {
+	It("should patch only the selected instances", func() {
+		myCR := &appsexamplecomv1alpha1.MiniCloneSet{Spec: appsexamplecomv1alpha1.MiniCloneSetSpec{
+			InstanceOverrides: []appsexamplecomv1alpha1.InstanceOverride{{
+				Selector: "2-3",
+				Patch: runtime.RawExtension{Raw: []byte(
+					`{"spec":{"containers":[{"name":"main","env":[{"name":"LOG_LEVEL","value":"debug"}]}]}}`)},
+			}},
+		}}
+		newPod := func(name string) *corev1.Pod {
+			return &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: name},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{
+					{Name: "main", Image: "nginx:1.20"},
+					{Name: "log-shipper", Image: "fluent-bit:3.1"},
+				}},
+			}
+		}
+
+		pod := newPod("web-3")
+		Expect(applyInstanceOverrides(myCR, pod, 3)).To(Succeed())
+		Expect(pod.Spec.Containers).To(HaveLen(2))
+		Expect(pod.Spec.Containers[0].Image).To(Equal("nginx:1.20"))
+		Expect(pod.Spec.Containers[0].Env).To(ConsistOf(corev1.EnvVar{Name: "LOG_LEVEL", Value: "debug"}))
+
+		pod = newPod("web-1")
+		Expect(applyInstanceOverrides(myCR, pod, 1)).To(Succeed())
+		Expect(pod.Spec.Containers[0].Env).To(BeEmpty())
+	})
+
+	It("should keep revision hashes of MiniCloneSets without overrides", func() {
+		myCR := &appsexamplecomv1alpha1.MiniCloneSet{Spec: appsexamplecomv1alpha1.MiniCloneSetSpec{Image: "nginx:1.20"}}
+		patch, err := getPatch(myCR)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(patch)).To(Equal(`{"spec":{"image":"nginx:1.20"}}`))
+
+		// Rolling back to such a revision clears the overrides
+		restored, err := restorePatch(patch)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(restored)).To(Equal(`{"spec":{"env":null,"image":"nginx:1.20","instanceOverrides":null,"podMetadata":null,"resources":null}}`))
+	})
+})
+
+var _ = Describe("In-place resize", func() {
+	resources := func(cpu string) corev1.ResourceRequirements {
+		return corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)}}
+	}
+
+	It("should resize only pods whose revision differs in resources", func() {
+		oldCR := &appsexamplecomv1alpha1.MiniCloneSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+			Spec:       appsexamplecomv1alpha1.MiniCloneSetSpec{Image: "nginx:1.20", Resources: resources("100m")},
+		}
+		data, err := getPatch(oldCR)
+		Expect(err).NotTo(HaveOccurred())
+		revision := &appsv1.ControllerRevision{
+			ObjectMeta: metav1.ObjectMeta{Name: revisionName(oldCR, "old"), Namespace: "default"},
+			Data:       runtime.RawExtension{Raw: data},
+		}
+		r := &MiniCloneSetReconciler{Client: fake.NewClientBuilder().WithObjects(revision).Build()}
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{appsv1.ControllerRevisionHashLabelKey: "old"},
+		}}
+
+		myCR := oldCR.DeepCopy()
+		myCR.Status.UpdateRevision = "new"
+		myCR.Spec.Resources = resources("200m")
+		inPlace, err := r.onlyResourcesChanged(ctx, myCR, pod)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(inPlace).To(BeTrue())
+
+		myCR.Spec.Image = "nginx:1.21"
+		inPlace, err = r.onlyResourcesChanged(ctx, myCR, pod)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(inPlace).To(BeFalse())
+	})
+
+	It("should relabel resized pods and leave pods that cannot be resized to the rolling update", func() {
+		myCR := &appsexamplecomv1alpha1.MiniCloneSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+			Spec:       appsexamplecomv1alpha1.MiniCloneSetSpec{Image: "nginx:1.20", Resources: resources("200m")},
+		}
+		newPod := func(name string, cpu string, conditions ...corev1.PodCondition) *corev1.Pod {
+			return &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        name,
+					Namespace:   "default",
+					Labels:      map[string]string{appsv1.ControllerRevisionHashLabelKey: "old"},
+					Annotations: map[string]string{appsexamplecomv1alpha1.ResizingToAnnotation: "new"},
+				},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "main", Image: "nginx:1.20"}}},
+				Status: corev1.PodStatus{
+					Conditions: conditions,
+					ContainerStatuses: []corev1.ContainerStatus{{
+						Name:      "main",
+						Resources: &corev1.ResourceRequirements{Requests: resources(cpu).Requests},
+					}},
+				},
+			}
+		}
+		resized := newPod("web-0", "200m")
+		pending := newPod("web-1", "100m", corev1.PodCondition{
+			Type: corev1.PodResizeInProgress, Status: corev1.ConditionTrue,
+		})
+		infeasible := newPod("web-2", "100m", corev1.PodCondition{
+			Type: corev1.PodResizePending, Status: corev1.ConditionTrue, Reason: corev1.PodReasonInfeasible,
+		})
+		r := &MiniCloneSetReconciler{
+			Client:   fake.NewClientBuilder().WithObjects(resized, pending, infeasible).Build(),
+			Recorder: record.NewFakeRecorder(10),
+		}
+
+		_, _, err := r.finishResize(ctx, myCR, resized, "new")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(r.Get(ctx, client.ObjectKeyFromObject(resized), resized)).To(Succeed())
+		Expect(resized.Labels[appsv1.ControllerRevisionHashLabelKey]).To(Equal("new"))
+		Expect(resized.Annotations).NotTo(HaveKey(appsexamplecomv1alpha1.ResizingToAnnotation))
+
+		_, _, err = r.finishResize(ctx, myCR, pending, "new")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(r.Get(ctx, client.ObjectKeyFromObject(pending), pending)).To(Succeed())
+		Expect(pending.Labels[appsv1.ControllerRevisionHashLabelKey]).To(Equal("old"))
+
+		// The infeasible pod is kept outdated instead of being deleted
+		podList := &corev1.PodList{Items: []corev1.Pod{*infeasible}}
+		resizing, _, err := r.syncInPlaceResize(context.Background(), myCR, podList, "new", 3)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resizing).To(BeFalse())
+		Expect(r.Get(ctx, client.ObjectKeyFromObject(infeasible), infeasible)).To(Succeed())
+		Expect(infeasible.Labels[appsv1.ControllerRevisionHashLabelKey]).To(Equal("old"))
+		Expect(infeasible.Annotations).NotTo(HaveKey(appsexamplecomv1alpha1.ResizingToAnnotation))
+	})
+})
+
+var _ = Describe("In-place metadata update", func() {
+	It("should patch pod metadata and restart only containers reading changed keys", func() {
+		old := &appsexamplecomv1alpha1.PodMetadata{
+			Labels:      map[string]string{"tier": "web", "team": "a"},
+			Annotations: map[string]string{"owner": "ops"},
+		}
+		new := &appsexamplecomv1alpha1.PodMetadata{
+			Labels:      map[string]string{"tier": "frontend", "app": "hijacked"},
+			Annotations: map[string]string{"owner": "ops"},
+		}
+		fieldEnv := func(path string) corev1.EnvVar {
+			return corev1.EnvVar{Name: "FIELD", ValueFrom: &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{FieldPath: path},
+			}}
+		}
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels:      map[string]string{"app": "web", "tier": "web", "team": "a"},
+				Annotations: map[string]string{"owner": "ops"},
+			},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{
+				{Name: "main", Env: []corev1.EnvVar{fieldEnv("metadata.labels['tier']")}},
+				{Name: "log-shipper", Env: []corev1.EnvVar{fieldEnv("metadata.annotations['owner']")}},
+			}},
+		}
+
+		updatePodMetadata(pod, old, new)
+		Expect(pod.Labels).To(Equal(map[string]string{"app": "web", "tier": "frontend"}))
+		Expect(pod.Annotations).To(Equal(map[string]string{"owner": "ops"}))
+		Expect(containersReading(pod, changedMetadataFields(old, new))).To(ConsistOf("main"))
+	})
+
+	It("should report a container restarted once its restart count went up", func() {
+		finishedAt := metav1.NewTime(time.Now().Truncate(time.Second))
+		pod := &corev1.Pod{
+			Spec: corev1.PodSpec{EphemeralContainers: []corev1.EphemeralContainer{{
+				EphemeralContainerCommon: corev1.EphemeralContainerCommon{
+					Name: restarterName("main", "new"),
+					Env:  []corev1.EnvVar{{Name: restartCountEnv, Value: "2"}},
+				},
+			}}},
+			Status: corev1.PodStatus{
+				EphemeralContainerStatuses: []corev1.ContainerStatus{{
+					Name:  restarterName("main", "new"),
+					State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{FinishedAt: finishedAt}},
+				}},
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name:         "main",
+					Ready:        true,
+					RestartCount: 2,
+					State:        corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+				}},
+			},
+		}
+		done, failed := restartState(pod, "main", "new", finishedAt.Time)
+		Expect(done).To(BeFalse())
+		Expect(failed).To(BeFalse())
+
+		// A main process ignoring SIGTERM never restarts
+		_, failed = restartState(pod, "main", "new", finishedAt.Add(2*restartTimeout))
+		Expect(failed).To(BeTrue())
+
+		pod.Status.ContainerStatuses[0].RestartCount = 3
+		done, failed = restartState(pod, "main", "new", finishedAt.Add(2*restartTimeout))
+		Expect(done).To(BeTrue())
+		Expect(failed).To(BeFalse())
+
+		pod.Status.ContainerStatuses[0].RestartCount = 2
+		pod.Status.EphemeralContainerStatuses[0].State.Terminated.ExitCode = 1
+		_, failed = restartState(pod, "main", "new", finishedAt.Time)
+		Expect(failed).To(BeTrue())
+	})
+
+	It("should keep restarter names valid and cap the restarters of a pod", func() {
+		name := restarterName(strings.Repeat("a", 50), "012-456")
+		Expect(len(name)).To(BeNumerically("<=", 63))
+		Expect(name).NotTo(HaveSuffix("-"))
+
+		pod := &corev1.Pod{}
+		for i := range maxRestarters - 1 {
+			pod.Spec.EphemeralContainers = append(pod.Spec.EphemeralContainers, corev1.EphemeralContainer{
+				EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: restarterName("main", fmt.Sprint(i))},
+			})
+		}
+		pod.Spec.EphemeralContainers = append(pod.Spec.EphemeralContainers, corev1.EphemeralContainer{
+			EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: "debugger"},
+		})
+		Expect(restartersLeft(pod)).To(Equal(1))
+	})
+})
+
+var _ = Describe("Image pre-pull", func() {
+	It("should wait for enough nodes to pull the image before pods are replaced", func() {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(appsexamplecomv1alpha1.AddToScheme(scheme)).To(Succeed())
+		r := &MiniCloneSetReconciler{Client: fake.NewClientBuilder().WithScheme(scheme).Build(), Scheme: scheme}
+		minPulled := intstr.FromString("50%")
+		myCR := &appsexamplecomv1alpha1.MiniCloneSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", UID: "web"},
+			Spec: appsexamplecomv1alpha1.MiniCloneSetSpec{
+				Image:   "nginx:1.21",
+				PrePull: &appsexamplecomv1alpha1.ImagePrePullPolicy{MinPulled: &minPulled, TimeoutSeconds: 300},
+			},
+		}
+		podList := &corev1.PodList{}
+		for _, node := range []string{"node-b", "node-a", "node-b"} {
+			podList.Items = append(podList.Items, corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{appsv1.ControllerRevisionHashLabelKey: "old"}},
+				Spec:       corev1.PodSpec{NodeName: node},
+			})
+		}
+		listPullers := func() []corev1.Pod {
+			var pullers corev1.PodList
+			Expect(r.List(ctx, &pullers, client.MatchingLabels{appsexamplecomv1alpha1.PrePullLabel: "web"})).To(Succeed())
+			return pullers.Items
+		}
+		now := time.Now()
+
+		pulled, err := r.syncImagePrePull(ctx, myCR, podList, "new", now)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pulled).To(BeFalse())
+		pullers := listPullers()
+		Expect(pullers).To(HaveLen(2))
+		Expect(myCR.Status.ImagePrePull.Nodes).To(Equal(2))
+		reason, _ := rolloutHold(myCR)
+		Expect(reason).To(BeEmpty())
+
+		// A pull that failed does not count, and replacing pods keeps the nodes
+		failed := pullers[1]
+		failed.Status.ContainerStatuses = []corev1.ContainerStatus{{
+			Name:  "pull",
+			State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1}},
+		}}
+		Expect(r.Status().Update(ctx, &failed)).To(Succeed())
+		podList.Items[1].Labels[appsv1.ControllerRevisionHashLabelKey] = "new"
+		pulled, err = r.syncImagePrePull(ctx, myCR, podList, "new", now)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pulled).To(BeFalse())
+		Expect(myCR.Status.ImagePrePull.Nodes).To(Equal(2))
+		Expect(myCR.Status.ImagePrePull.Pulled).To(Equal(0))
+
+		puller := pullers[0]
+		puller.Status.ContainerStatuses = []corev1.ContainerStatus{{Name: "pull", ImageID: "docker.io/library/nginx@sha256:0"}}
+		Expect(r.Status().Update(ctx, &puller)).To(Succeed())
+		pulled, err = r.syncImagePrePull(ctx, myCR, podList, "new", now.Add(time.Second))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pulled).To(BeTrue())
+		Expect(myCR.Status.ImagePrePull.Pulled).To(Equal(1))
+		Expect(myCR.Status.ImagePrePull.Completed).To(BeTrue())
+		Expect(listPullers()).To(BeEmpty())
+	})
+
+	It("should count an image pulled even when the puller cannot run", func() {
+		myCR := &appsexamplecomv1alpha1.MiniCloneSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+			Spec: appsexamplecomv1alpha1.MiniCloneSetSpec{
+				Image:   "gcr.io/distroless/static",
+				PrePull: &appsexamplecomv1alpha1.ImagePrePullPolicy{TimeoutSeconds: 300},
+			},
+		}
+		puller := newImagePuller(myCR, "node-a", "new")
+		Expect(puller.Spec.Containers[0].Resources.Requests).To(Equal(pullerResources))
+		Expect(puller.Spec.Containers[0].Resources.Limits).To(Equal(pullerResources))
+
+		puller.Status.ContainerStatuses = []corev1.ContainerStatus{{
+			Name:  "pull",
+			State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ErrImagePull"}},
+		}}
+		Expect(imagePulled(puller)).To(BeFalse())
+		puller.Status.ContainerStatuses[0].State = corev1.ContainerState{
+			Terminated: &corev1.ContainerStateTerminated{Reason: "StartError", ExitCode: 128},
+		}
+		Expect(imagePulled(puller)).To(BeTrue())
+		puller.Status.ContainerStatuses[0].State = corev1.ContainerState{
+			Waiting: &corev1.ContainerStateWaiting{Reason: "CreateContainerError"},
+		}
+		Expect(imagePulled(puller)).To(BeTrue())
+	})
+})
+
+var _ = Describe("Managed Service", func() {
+	It("should create, update and delete the Service with spec.service", func() {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(appsexamplecomv1alpha1.AddToScheme(scheme)).To(Succeed())
+		r := &MiniCloneSetReconciler{Client: fake.NewClientBuilder().WithScheme(scheme).Build(), Scheme: scheme}
+		myCR := &appsexamplecomv1alpha1.MiniCloneSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", UID: "web"},
+			Spec: appsexamplecomv1alpha1.MiniCloneSetSpec{
+				UpdateStrategy: appsexamplecomv1alpha1.BlueGreenStrategyType,
+				Service:        &appsexamplecomv1alpha1.ServiceSpec{Type: corev1.ServiceTypeNodePort},
+			},
+		}
+		key := types.NamespacedName{Namespace: "default", Name: "web"}
+
+		Expect(r.syncService(ctx, myCR)).To(Succeed())
+		svc := &corev1.Service{}
+		Expect(r.Get(ctx, key, svc)).To(Succeed())
+		Expect(svc.Spec.Selector).To(Equal(map[string]string{"app": "web", appsexamplecomv1alpha1.ActiveLabel: "true"}))
+		Expect(svc.Spec.Ports).To(HaveLen(1))
+		Expect(svc.Spec.Ports[0].Port).To(Equal(int32(80)))
+		Expect(svc.Spec.Ports[0].TargetPort).To(Equal(intstr.FromInt32(80)))
+
+		// Allocated node ports survive a change of the ports
+		svc.Spec.Ports[0].NodePort = 30080
+		Expect(r.Update(ctx, svc)).To(Succeed())
+		myCR.Spec.Service.Ports = []appsexamplecomv1alpha1.ServicePort{
+			{Name: "http", Port: 80},
+			{Name: "metrics", Port: 9090},
+		}
+		Expect(r.syncService(ctx, myCR)).To(Succeed())
+		Expect(r.Get(ctx, key, svc)).To(Succeed())
+		Expect(svc.Spec.Ports).To(HaveLen(2))
+		Expect(svc.Spec.Ports[0].NodePort).To(Equal(int32(30080)))
+		Expect(svc.Spec.Ports[1].Protocol).To(Equal(corev1.ProtocolTCP))
+
+		myCR.Spec.Service = nil
+		Expect(r.syncService(ctx, myCR)).To(Succeed())
+		Expect(errors.IsNotFound(r.Get(ctx, key, svc))).To(BeTrue())
+	})
+
+	It("should leave a Service it does not control alone", func() {
+		existing := &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+			Spec: corev1.ServiceSpec{
+				ClusterIP: "10.0.0.10",
+				Selector:  map[string]string{"app": "legacy"},
+				Ports:     []corev1.ServicePort{{Name: "http", Port: 8080}},
+			},
+		}
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(appsexamplecomv1alpha1.AddToScheme(scheme)).To(Succeed())
+		recorder := record.NewFakeRecorder(10)
+		r := &MiniCloneSetReconciler{
+			Client:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(existing).Build(),
+			Scheme:   scheme,
+			Recorder: recorder,
+		}
+		myCR := &appsexamplecomv1alpha1.MiniCloneSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", UID: "web"},
+			Spec: appsexamplecomv1alpha1.MiniCloneSetSpec{
+				Service: &appsexamplecomv1alpha1.ServiceSpec{Headless: true},
+			},
+		}
+		key := types.NamespacedName{Namespace: "default", Name: "web"}
+		svc := &corev1.Service{}
+
+		Expect(r.syncService(ctx, myCR)).To(Succeed())
+		Expect(recorder.Events).To(Receive(ContainSubstring(appsexamplecomv1alpha1.ServiceConflictReason)))
+		Expect(r.Get(ctx, key, svc)).To(Succeed())
+		Expect(svc.OwnerReferences).To(BeEmpty())
+		Expect(svc.Spec.Selector).To(Equal(map[string]string{"app": "legacy"}))
+
+		myCR.Spec.Service = nil
+		Expect(r.syncService(ctx, myCR)).To(Succeed())
+		Expect(r.Get(ctx, key, svc)).To(Succeed())
+	})
+})
+
+var _ = Describe("Managed PodDisruptionBudget", func() {
+	It("should turn the budget into a number of pods that follows the replicas", func() {
+		percent := intstr.FromString("25%")
+		minAvailable, err := minAvailablePods(&appsexamplecomv1alpha1.DisruptionBudget{MaxUnavailable: &percent}, 10)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(minAvailable).To(Equal(intstr.FromInt32(7)))
+
+		minAvailable, err = minAvailablePods(&appsexamplecomv1alpha1.DisruptionBudget{MinAvailable: &percent}, 10)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(minAvailable).To(Equal(intstr.FromInt32(3)))
+	})
+
+	It("should rescale with the replicas and be removed when cleared", func() {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(appsexamplecomv1alpha1.AddToScheme(scheme)).To(Succeed())
+		r := &MiniCloneSetReconciler{Client: fake.NewClientBuilder().WithScheme(scheme).Build(), Scheme: scheme}
+		maxUnavailable := intstr.FromInt32(1)
+		myCR := &appsexamplecomv1alpha1.MiniCloneSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", UID: "web"},
+			Spec: appsexamplecomv1alpha1.MiniCloneSetSpec{
+				DisruptionBudget: &appsexamplecomv1alpha1.DisruptionBudget{MaxUnavailable: &maxUnavailable},
+			},
+		}
+		key := types.NamespacedName{Namespace: "default", Name: "web"}
+		pdb := &policyv1.PodDisruptionBudget{}
+
+		Expect(r.syncDisruptionBudget(ctx, myCR, 3)).To(Succeed())
+		Expect(r.Get(ctx, key, pdb)).To(Succeed())
+		Expect(pdb.Spec.MinAvailable.IntValue()).To(Equal(2))
+		Expect(pdb.Spec.Selector.MatchLabels).To(Equal(map[string]string{"app": "web"}))
+
+		Expect(r.syncDisruptionBudget(ctx, myCR, 5)).To(Succeed())
+		Expect(r.Get(ctx, key, pdb)).To(Succeed())
+		Expect(pdb.Spec.MinAvailable.IntValue()).To(Equal(4))
+
+		myCR.Spec.DisruptionBudget = nil
+		Expect(r.syncDisruptionBudget(ctx, myCR, 5)).To(Succeed())
+		Expect(errors.IsNotFound(r.Get(ctx, key, pdb))).To(BeTrue())
+	})
+})
+
+var _ = Describe("Teardown", func() {
+	It("should delete pods in batches, wait for PreDelete hooks and then release the finalizer", func() {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(appsexamplecomv1alpha1.AddToScheme(scheme)).To(Succeed())
+		maxUnavailable := intstr.FromInt32(1)
+		myCR := &appsexamplecomv1alpha1.MiniCloneSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "web",
+				Namespace:         "default",
+				UID:               "web",
+				Finalizers:        []string{appsexamplecomv1alpha1.TeardownFinalizer},
+				DeletionTimestamp: &metav1.Time{Time: time.Now()},
+			},
+			Spec: appsexamplecomv1alpha1.MiniCloneSetSpec{
+				Replicas:       3,
+				MaxUnavailable: &maxUnavailable,
+				PreDelete:      &appsexamplecomv1alpha1.PreDeleteHook{LabelsHandler: map[string]string{"drain": "pending"}},
+			},
+		}
+		builder := fake.NewClientBuilder().WithScheme(scheme).WithObjects(myCR)
+		for i := range 3 {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Name:            fmt.Sprintf("web-%d", i),
+				Namespace:       "default",
+				Labels:          map[string]string{"app": "web"},
+				OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(myCR, appsexamplecomv1alpha1.GroupVersion.WithKind("MiniCloneSet"))},
+			}}
+			if i == 1 {
+				addPreDeleteLabels(myCR, pod)
+			}
+			builder = builder.WithObjects(pod)
+		}
+		r := &MiniCloneSetReconciler{Client: builder.Build(), Scheme: scheme, Recorder: record.NewFakeRecorder(10)}
+		key := types.NamespacedName{Namespace: "default", Name: "web"}
+		teardown := func() []string {
+			current := &appsexamplecomv1alpha1.MiniCloneSet{}
+			Expect(r.Get(ctx, key, current)).To(Succeed())
+			Expect(r.teardown(ctx, current)).Error().NotTo(HaveOccurred())
+			var pods corev1.PodList
+			Expect(r.List(ctx, &pods)).To(Succeed())
+			var names []string
+			for _, pod := range pods.Items {
+				names = append(names, pod.Name)
+			}
+			return names
+		}
+
+		Expect(teardown()).To(Equal([]string{"web-1", "web-2"}))
+
+		By("holding the pod with a PreDelete hook and the rest of the pods back")
+		Expect(teardown()).To(Equal([]string{"web-1", "web-2"}))
+		Expect(teardown()).To(Equal([]string{"web-1", "web-2"}))
+		pod := &corev1.Pod{}
+		Expect(r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "web-1"}, pod)).To(Succeed())
+		Expect(pod.Labels).To(HaveKeyWithValue(appsexamplecomv1alpha1.LifecycleStateLabel, appsexamplecomv1alpha1.PreparingDeleteState))
+
+		By("deleting the pod once the hook label is removed")
+		delete(pod.Labels, "drain")
+		Expect(r.Update(ctx, pod)).To(Succeed())
+		Expect(teardown()).To(Equal([]string{"web-2"}))
+		Expect(teardown()).To(BeEmpty())
+
+		By("releasing the finalizer once every pod is gone")
+		current := &appsexamplecomv1alpha1.MiniCloneSet{}
+		Expect(r.Get(ctx, key, current)).To(Succeed())
+		Expect(r.teardown(ctx, current)).Error().NotTo(HaveOccurred())
+		Expect(errors.IsNotFound(r.Get(ctx, key, current))).To(BeTrue())
+	})
+
+	It("should keep requeueing when a pod disappears while it is torn down", func() {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(appsexamplecomv1alpha1.AddToScheme(scheme)).To(Succeed())
+		myCR := &appsexamplecomv1alpha1.MiniCloneSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "web",
+				Namespace:         "default",
+				UID:               "web",
+				Finalizers:        []string{appsexamplecomv1alpha1.TeardownFinalizer},
+				DeletionTimestamp: &metav1.Time{Time: time.Now()},
+			},
+			Spec: appsexamplecomv1alpha1.MiniCloneSetSpec{
+				Replicas:  2,
+				PreDelete: &appsexamplecomv1alpha1.PreDeleteHook{LabelsHandler: map[string]string{"drain": "pending"}},
+			},
+		}
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:            "web-0",
+			Namespace:       "default",
+			Labels:          map[string]string{"app": "web"},
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(myCR, appsexamplecomv1alpha1.GroupVersion.WithKind("MiniCloneSet"))},
+		}}
+		addPreDeleteLabels(myCR, pod)
+		gone := func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+			return errors.NewNotFound(corev1.Resource("pods"), obj.GetName())
+		}
+		r := &MiniCloneSetReconciler{
+			Client:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(myCR, pod).WithInterceptorFuncs(interceptor.Funcs{Patch: gone}).Build(),
+			Scheme:   scheme,
+			Recorder: record.NewFakeRecorder(10),
+		}
+
+		result, err := r.teardown(context.Background(), myCR)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(5 * time.Second))
+	})
+})
+
+var _ = Describe("Disruption budget", func() {
+	const controllerUsername = "system:serviceaccount:system:controller-manager"
+
+	// newReconciler sends pod deletes through the pod unavailable budget
+	// webhook as the given user, the way the API server would
+	newReconciler := func(username string, objs ...client.Object) *MiniCloneSetReconciler {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(appsexamplecomv1alpha1.AddToScheme(scheme)).To(Succeed())
+		validator := &webhookv1.PodUnavailableBudgetValidator{
+			Decoder:            admission.NewDecoder(scheme),
+			ControllerUsername: controllerUsername,
+		}
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).
+			WithStatusSubresource(&appsexamplecomv1alpha1.MiniCloneSet{}).
+			WithInterceptorFuncs(interceptor.Funcs{
+				Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+					if pod, ok := obj.(*corev1.Pod); ok {
+						raw, err := json.Marshal(pod)
+						Expect(err).NotTo(HaveOccurred())
+						response := validator.Handle(ctx, admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+							Operation: admissionv1.Delete,
+							Namespace: pod.Namespace,
+							Name:      pod.Name,
+							OldObject: runtime.RawExtension{Raw: raw},
+							UserInfo:  authenticationv1.UserInfo{Username: username},
+						}})
+						if !response.Allowed {
+							return errors.NewForbidden(corev1.Resource("pods"), pod.Name, fmt.Errorf("%s", response.Result.Message))
+						}
+					}
+					return c.Delete(ctx, obj, opts...)
+				},
+			}).Build()
+		validator.Client = c
+		return &MiniCloneSetReconciler{Client: c, Scheme: scheme, Recorder: record.NewFakeRecorder(10)}
+	}
+	newCloneSet := func() *appsexamplecomv1alpha1.MiniCloneSet {
+		maxUnavailable := intstr.FromInt32(1)
+		return &appsexamplecomv1alpha1.MiniCloneSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", UID: "web", Generation: 1},
+			Spec: appsexamplecomv1alpha1.MiniCloneSetSpec{
+				Replicas:         3,
+				Image:            "nginx:1.21",
+				UpdateStrategy:   appsexamplecomv1alpha1.RecreateStrategyType,
+				DisruptionBudget: &appsexamplecomv1alpha1.DisruptionBudget{MaxUnavailable: &maxUnavailable},
+			},
+			Status: appsexamplecomv1alpha1.MiniCloneSetStatus{ObservedGeneration: 1, DesiredReplicas: 3},
+		}
+	}
+	newPods := func(myCR *appsexamplecomv1alpha1.MiniCloneSet) []client.Object {
+		pods := []client.Object{}
+		for i := range 3 {
+			pods = append(pods, &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      fmt.Sprintf("web-%d", i),
+					Namespace: "default",
+					Labels: map[string]string{
+						"app":                                 "web",
+						appsv1.ControllerRevisionHashLabelKey: "old",
+					},
+					OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(myCR, appsexamplecomv1alpha1.GroupVersion.WithKind("MiniCloneSet"))},
+				},
+				Status: corev1.PodStatus{Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}},
+			})
+		}
+		return pods
+	}
+	listPods := func(r *MiniCloneSetReconciler) *corev1.PodList {
+		podList := &corev1.PodList{}
+		Expect(r.List(context.Background(), podList, client.InNamespace("default"), client.MatchingLabels{"app": "web"})).To(Succeed())
+		return podList
+	}
+
+	It("should let a Recreate update delete every pod", func() {
+		myCR := newCloneSet()
+		r := newReconciler(controllerUsername, append(newPods(myCR), myCR)...)
+
+		_, err := r.handleRecreateUpdate(context.Background(), myCR, listPods(r), 3, "new")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(listPods(r).Items).To(BeEmpty())
+	})
+
+	It("should requeue instead of failing when the budget denies a delete", func() {
+		myCR := newCloneSet()
+		r := newReconciler("alice", append(newPods(myCR), myCR)...)
+
+		result, err := r.handleRecreateUpdate(context.Background(), myCR, listPods(r), 3, "new")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(budgetRequeue))
+		Expect(listPods(r).Items).To(HaveLen(2))
+	})
+
+	It("should save the hibernation before its deletes are denied and finish once saved", func() {
+		myCR := newCloneSet()
+		myCR.Spec.Hibernate = true
+		myCR.Status.CurrentRevision = "old"
+		r := newReconciler("alice", append(newPods(myCR), myCR)...)
+
+		podList := listPods(r)
+		hibernating, result, err := r.syncHibernation(context.Background(), myCR, podList, 0, 3)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(hibernating).To(BeTrue())
+		Expect(result.RequeueAfter).To(Equal(budgetRequeue))
+		Expect(podList.Items).To(HaveLen(2))
+		Expect(myCR.Status.Hibernation).NotTo(BeNil())
+		Expect(myCR.Status.DesiredReplicas).To(BeZero())
+
+		// With no desired replicas saved, the budget lets the rest go
+		Expect(r.Status().Update(context.Background(), myCR)).To(Succeed())
+		_, _, err = r.syncHibernation(context.Background(), myCR, listPods(r), 0, 0)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(listPods(r).Items).To(BeEmpty())
+		Expect(myCR.Status.Hibernation.Replicas).To(Equal(3))
+	})
 })
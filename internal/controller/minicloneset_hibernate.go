@@ -0,0 +1,140 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	appsexamplecomv1alpha1 "k8s.openkruise.com/v1/api/v1alpha1"
+)
+
+// syncHibernation deletes every pod of a hibernating MiniCloneSet and restores
+// them once hibernate is turned off again. It reports whether the MiniCloneSet
+// is hibernating or still waking up, in which case nothing else may touch its pods.
+func (r *MiniCloneSetReconciler) syncHibernation(ctx context.Context, myCR *appsexamplecomv1alpha1.MiniCloneSet, podList *corev1.PodList, terminating int, desiredReplicas int) (bool, ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+	status := &myCR.Status
+
+	if !myCR.Spec.Hibernate {
+		if status.Hibernation == nil {
+			return false, ctrl.Result{}, nil
+		}
+		return r.wakeUp(ctx, myCR, podList, terminating)
+	}
+
+	// Remember the last fully rolled out revision, the rollout resumes after waking up
+	if status.Hibernation == nil {
+		revision := status.CurrentRevision
+		if revision == "" {
+			revision = status.UpdateRevision
+		}
+		status.Hibernation = &appsexamplecomv1alpha1.HibernationStatus{
+			Replicas:     desiredReplicas,
+			Revision:     revision,
+			HibernatedAt: metav1.Now(),
+		}
+		log.Info("Hibernating MiniCloneSet", "replicas", desiredReplicas, "revision", revision)
+		r.Recorder.Eventf(myCR, corev1.EventTypeNormal, appsexamplecomv1alpha1.HibernatedReason,
+			"Hibernating, %d pods of revision %s will be restored on wake up", desiredReplicas, revision)
+	}
+	status.DesiredReplicas = 0
+
+	// Denied deletes are retried once the status above is saved, as the
+	// disruption budget is computed from the desired replicas
+	remaining := podList.Items[:0]
+	for i := range podList.Items {
+		if err := r.Delete(ctx, &podList.Items[i]); err != nil && !apierrors.IsNotFound(err) {
+			if budgetDenied(err) {
+				log.Info("Disruption budget denied deleting pod, retrying later", "pod", podList.Items[i].Name)
+				remaining = append(remaining, podList.Items[i])
+				continue
+			}
+			log.Error(err, "failed to delete pod for hibernation", "pod", podList.Items[i].Name)
+			return true, ctrl.Result{}, err
+		}
+		log.Info("Deleted pod for hibernation", "pod", podList.Items[i].Name)
+	}
+	podList.Items = remaining
+	if len(remaining) > 0 {
+		return true, ctrl.Result{RequeueAfter: budgetRequeue}, nil
+	}
+	return true, ctrl.Result{}, nil
+}
+
+// wakeUp recreates the pods recorded when the MiniCloneSet started hibernating.
+// Once they all exist the hibernation status is cleared and the MiniCloneSet
+// is reconciled as usual, so template changes made in the meantime roll out
+// with the configured update strategy.
+func (r *MiniCloneSetReconciler) wakeUp(ctx context.Context, myCR *appsexamplecomv1alpha1.MiniCloneSet, podList *corev1.PodList, terminating int) (bool, ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+	status := &myCR.Status
+	hibernation := status.Hibernation
+
+	if len(podList.Items) >= hibernation.Replicas {
+		log.Info("Woke up MiniCloneSet", "replicas", hibernation.Replicas, "revision", hibernation.Revision)
+		r.Recorder.Eventf(myCR, corev1.EventTypeNormal, appsexamplecomv1alpha1.WokeUpReason,
+			"Restored %d pods of revision %s", hibernation.Replicas, hibernation.Revision)
+		status.Hibernation = nil
+		return false, ctrl.Result{}, nil
+	}
+
+	status.DesiredReplicas = hibernation.Replicas
+	if terminating > 0 {
+		// Pods still going away from hibernating may hold the names we need
+		return true, ctrl.Result{RequeueAfter: time.Second * 5}, nil
+	}
+
+	revision := hibernation.Revision
+	template, err := r.templateForRevision(ctx, myCR, revision)
+	if apierrors.IsNotFound(err) {
+		log.Info("Hibernated revision no longer exists, restoring the update revision", "revision", revision)
+		template, revision = myCR, status.UpdateRevision
+	} else if err != nil {
+		log.Error(err, "failed to fetch hibernated revision", "revision", revision)
+		return true, ctrl.Result{}, err
+	}
+
+	for i := len(podList.Items); i < hibernation.Replicas; i++ {
+		pod, err := r.createPodForMiniCloneSet(ctx, template, podList, revision)
+		if err != nil {
+			return true, ctrl.Result{}, err
+		}
+		if revision == status.ActiveRevision {
+			pod.Labels[appsexamplecomv1alpha1.ActiveLabel] = "true"
+		}
+		if err := r.Create(ctx, pod); err != nil {
+			log.Error(err, "failed to create pod", "pod", pod.Name)
+			return true, ctrl.Result{}, client.IgnoreAlreadyExists(err)
+		}
+		podList.Items = append(podList.Items, *pod)
+		log.Info("Restored pod after hibernation", "pod", pod.Name)
+	}
+	return true, ctrl.Result{RequeueAfter: time.Second * 10}, nil
+}
+
+// isHibernatedRevision checks if the given revision must be kept to wake the MiniCloneSet up
+func isHibernatedRevision(myCR *appsexamplecomv1alpha1.MiniCloneSet, hash string) bool {
+	return myCR.Status.Hibernation != nil && myCR.Status.Hibernation.Revision == hash
+}
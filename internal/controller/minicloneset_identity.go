@@ -0,0 +1,66 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"strconv"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/rand"
+
+	appsexamplecomv1alpha1 "k8s.openkruise.com/v1/api/v1alpha1"
+)
+
+// Environment variables every container of a pod gets its identity from
+const (
+	nameEnvVar       = "MINICLONESET_NAME"
+	instanceIDEnvVar = "MINICLONESET_INSTANCE_ID"
+	ordinalEnvVar    = "MINICLONESET_ORDINAL"
+	replicasEnvVar   = "MINICLONESET_REPLICAS"
+	revisionEnvVar   = "MINICLONESET_REVISION"
+)
+
+// injectIdentity labels a pod with its instance ID and ordinal, next to the
+// app and revision hash labels it already has, and exposes all of them,
+// along with the replica count, to every container. The replica count is the
+// one at creation time and is not updated when the MiniCloneSet scales.
+//
+// In-place updates move a pod to a new revision by relabeling it, so the
+// revision is read from the label through the downward API. Like any
+// environment variable it is resolved when the container starts: containers
+// that were not restarted by the update keep reporting the old revision.
+func injectIdentity(myCR *appsexamplecomv1alpha1.MiniCloneSet, pod *corev1.Pod, index int) {
+	instanceID := rand.String(8)
+	pod.Labels[appsexamplecomv1alpha1.InstanceIDLabel] = instanceID
+	pod.Labels[appsexamplecomv1alpha1.OrdinalLabel] = strconv.Itoa(index)
+
+	env := []corev1.EnvVar{
+		{Name: nameEnvVar, Value: myCR.Name},
+		{Name: instanceIDEnvVar, Value: instanceID},
+		{Name: ordinalEnvVar, Value: strconv.Itoa(index)},
+		{Name: replicasEnvVar, Value: strconv.Itoa(myCR.Status.DesiredReplicas)},
+		{Name: revisionEnvVar, ValueFrom: &corev1.EnvVarSource{
+			FieldRef: &corev1.ObjectFieldSelector{FieldPath: fmt.Sprintf("metadata.labels['%s']", appsv1.ControllerRevisionHashLabelKey)},
+		}},
+	}
+	for i := range pod.Spec.Containers {
+		container := &pod.Spec.Containers[i]
+		container.Env = append(append([]corev1.EnvVar{}, container.Env...), env...)
+	}
+}
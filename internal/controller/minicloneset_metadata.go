@@ -0,0 +1,236 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	appsexamplecomv1alpha1 "k8s.openkruise.com/v1/api/v1alpha1"
+)
+
+// syncInPlaceMetadata moves pods whose revision only differs from the desired
+// one in pod metadata to the desired revision by patching their labels and
+// annotations. Containers reading a changed key through the downward API are
+// restarted in place, and the pod is relabeled once they run again; pods
+// whose restart fails are deleted so they get recreated, and pods without
+// restarters left are left to the rolling update. One pod is updated
+// at a time. It reports whether it acted, in which case the caller must not
+// replace any pod in this reconcile.
+func (r *MiniCloneSetReconciler) syncInPlaceMetadata(ctx context.Context, myCR *appsexamplecomv1alpha1.MiniCloneSet, podList *corev1.PodList, desiredRevision string, maxUpdated int) (bool, ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	updatedPods := 0
+	var candidate *corev1.Pod
+	var candidateTemplate *appsexamplecomv1alpha1.MiniCloneSet
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if isPodUpToDate(pod, desiredRevision) {
+			updatedPods++
+			continue
+		}
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		if candidate != nil && pod.Annotations[appsexamplecomv1alpha1.RestartingToAnnotation] != desiredRevision {
+			continue
+		}
+		template, inPlace, err := r.differsOnlyIn(ctx, myCR, pod, func(t *appsexamplecomv1alpha1.MiniCloneSet) {
+			t.Spec.PodMetadata = nil
+		})
+		if err != nil {
+			return false, ctrl.Result{}, err
+		}
+		if !inPlace {
+			continue
+		}
+		if pod.Annotations[appsexamplecomv1alpha1.RestartingToAnnotation] == desiredRevision {
+			return r.finishRestart(ctx, myCR, pod, template, desiredRevision)
+		}
+		// Pods out of restarters are replaced by the rolling update instead
+		restart := containersReading(pod, changedMetadataFields(template.Spec.PodMetadata, myCR.Spec.PodMetadata))
+		if len(restart) > restartersLeft(pod) {
+			continue
+		}
+		candidate, candidateTemplate = pod, template
+	}
+	if candidate == nil || updatedPods >= maxUpdated {
+		return false, ctrl.Result{}, nil
+	}
+
+	// Respect the pod update rate limit before updating another pod
+	if delay := r.reservePodUpdate(myCR); delay > 0 {
+		log.Info("Pod update rate limit reached", "pod", candidate.Name, "retryAfter", delay)
+		return true, ctrl.Result{RequeueAfter: delay}, nil
+	}
+
+	patch := client.MergeFrom(candidate.DeepCopy())
+	updatePodMetadata(candidate, candidateTemplate.Spec.PodMetadata, myCR.Spec.PodMetadata)
+	restart := containersReading(candidate, changedMetadataFields(candidateTemplate.Spec.PodMetadata, myCR.Spec.PodMetadata))
+	if len(restart) == 0 {
+		candidate.Labels[appsv1.ControllerRevisionHashLabelKey] = desiredRevision
+	} else {
+		if candidate.Annotations == nil {
+			candidate.Annotations = map[string]string{}
+		}
+		candidate.Annotations[appsexamplecomv1alpha1.RestartingToAnnotation] = desiredRevision
+	}
+	if err := r.Patch(ctx, candidate, patch); err != nil {
+		return false, ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if len(restart) == 0 {
+		log.Info("Updated pod metadata in place", "pod", candidate.Name, "revision", desiredRevision)
+		return true, ctrl.Result{RequeueAfter: time.Second * 5}, nil
+	}
+	log.Info("Updated pod metadata in place, restarting containers", "pod", candidate.Name, "containers", restart)
+	return r.finishRestart(ctx, myCR, candidate, candidateTemplate, desiredRevision)
+}
+
+// finishRestart restarts the containers of a pod whose metadata was updated
+// in place and moves the pod to the desired revision once they run again.
+// Only restarted containers pick up the new revision environment variable,
+// see injectIdentity.
+func (r *MiniCloneSetReconciler) finishRestart(ctx context.Context, myCR *appsexamplecomv1alpha1.MiniCloneSet, pod *corev1.Pod, template *appsexamplecomv1alpha1.MiniCloneSet, desiredRevision string) (bool, ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	restart := containersReading(pod, changedMetadataFields(template.Spec.PodMetadata, myCR.Spec.PodMetadata))
+	if err := addRestarters(ctx, r.Client, pod, restart, desiredRevision); err != nil {
+		if budgetDenied(err) {
+			log.Info("Disruption budget denied restarting containers, retrying later", "pod", pod.Name)
+			return true, ctrl.Result{RequeueAfter: budgetRequeue}, nil
+		}
+		log.Error(err, "failed to restart containers", "pod", pod.Name)
+		return false, ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	for _, container := range restart {
+		done, failed := restartState(pod, container, desiredRevision, time.Now())
+		if failed {
+			if err := r.Delete(ctx, pod); err != nil {
+				if budgetDenied(err) {
+					return true, ctrl.Result{RequeueAfter: budgetRequeue}, nil
+				}
+				return false, ctrl.Result{}, client.IgnoreNotFound(err)
+			}
+			r.Recorder.Eventf(myCR, corev1.EventTypeWarning, appsexamplecomv1alpha1.RestartFailedReason,
+				"Container %s of pod %s could not be restarted in place, recreating the pod", container, pod.Name)
+			return true, ctrl.Result{RequeueAfter: time.Second * 5}, nil
+		}
+		if !done {
+			return true, ctrl.Result{RequeueAfter: time.Second * 5}, nil
+		}
+	}
+
+	patch := client.MergeFrom(pod.DeepCopy())
+	pod.Labels[appsv1.ControllerRevisionHashLabelKey] = desiredRevision
+	delete(pod.Annotations, appsexamplecomv1alpha1.RestartingToAnnotation)
+	if err := r.Patch(ctx, pod, patch); err != nil {
+		return false, ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	log.Info("Restarted containers in place", "pod", pod.Name, "revision", desiredRevision)
+	return true, ctrl.Result{RequeueAfter: time.Second * 5}, nil
+}
+
+// updatePodMetadata moves the labels and annotations of a pod from the old to
+// the new template metadata: keys the new one sets are set and keys only the
+// old one set are removed. Keys used by the controller are left alone.
+func updatePodMetadata(pod *corev1.Pod, old, new *appsexamplecomv1alpha1.PodMetadata) {
+	oldMeta, newMeta := podMetadataOrEmpty(old), podMetadataOrEmpty(new)
+	if pod.Labels == nil {
+		pod.Labels = map[string]string{}
+	}
+	if pod.Annotations == nil {
+		pod.Annotations = map[string]string{}
+	}
+	for _, keys := range []struct{ current, old, new map[string]string }{
+		{pod.Labels, oldMeta.Labels, newMeta.Labels},
+		{pod.Annotations, oldMeta.Annotations, newMeta.Annotations},
+	} {
+		for key := range keys.old {
+			if _, ok := keys.new[key]; !ok && !reservedMetadataKey(key) {
+				delete(keys.current, key)
+			}
+		}
+		for key, value := range keys.new {
+			if !reservedMetadataKey(key) {
+				keys.current[key] = value
+			}
+		}
+	}
+}
+
+// changedMetadataFields returns the downward API field paths, such as
+// metadata.labels['tier'], whose value differs between two pod metadata
+func changedMetadataFields(old, new *appsexamplecomv1alpha1.PodMetadata) map[string]bool {
+	oldMeta, newMeta := podMetadataOrEmpty(old), podMetadataOrEmpty(new)
+	changed := map[string]bool{}
+	for _, keys := range []struct {
+		path     string
+		old, new map[string]string
+	}{
+		{"metadata.labels", oldMeta.Labels, newMeta.Labels},
+		{"metadata.annotations", oldMeta.Annotations, newMeta.Annotations},
+	} {
+		for key, value := range keys.old {
+			if newValue, ok := keys.new[key]; !ok || newValue != value {
+				changed[fmt.Sprintf("%s['%s']", keys.path, key)] = true
+			}
+		}
+		for key := range keys.new {
+			if _, ok := keys.old[key]; !ok {
+				changed[fmt.Sprintf("%s['%s']", keys.path, key)] = true
+			}
+		}
+	}
+	return changed
+}
+
+// containersReading returns the containers of the pod with an environment
+// variable taken from one of the given field paths
+func containersReading(pod *corev1.Pod, fields map[string]bool) []string {
+	containers := []string{}
+	for _, container := range pod.Spec.Containers {
+		for _, env := range container.Env {
+			if env.ValueFrom != nil && env.ValueFrom.FieldRef != nil && fields[env.ValueFrom.FieldRef.FieldPath] {
+				containers = append(containers, container.Name)
+				break
+			}
+		}
+	}
+	return containers
+}
+
+// reservedMetadataKey checks if a label or annotation key is set by the
+// controller and so cannot be set through the template's pod metadata
+func reservedMetadataKey(key string) bool {
+	return key == "app" || key == appsv1.ControllerRevisionHashLabelKey ||
+		strings.HasPrefix(key, "apps.example.com.my.domain/")
+}
+
+func podMetadataOrEmpty(meta *appsexamplecomv1alpha1.PodMetadata) appsexamplecomv1alpha1.PodMetadata {
+	if meta == nil {
+		return appsexamplecomv1alpha1.PodMetadata{}
+	}
+	return *meta
+}
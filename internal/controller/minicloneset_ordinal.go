@@ -0,0 +1,146 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	appsexamplecomv1alpha1 "k8s.openkruise.com/v1/api/v1alpha1"
+)
+
+// handleOrdinalUpdate implements rolling update for pods with stable identity.
+// Outdated pods are deleted one at a time from the highest ordinal down and
+// recreated under the same name once they are gone.
+func (r *MiniCloneSetReconciler) handleOrdinalUpdate(ctx context.Context, myCR *appsexamplecomv1alpha1.MiniCloneSet, podList *corev1.PodList, desiredReplicas int, desiredRevision string, maxUpdated int) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	// Missing ordinals are filled in, lowest first, with the desired revision
+	if len(podList.Items) != desiredReplicas {
+		return r.handleScale(ctx, myCR, podList, desiredReplicas, desiredRevision)
+	}
+
+	// A pod beyond the last ordinal means a lower one is missing, so move it down
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if podOrdinal(myCR, pod) < desiredReplicas {
+			continue
+		}
+		if err := r.Delete(ctx, pod); err != nil {
+			if budgetDenied(err) {
+				log.Info("Disruption budget denied deleting pod, retrying later", "pod", pod.Name)
+				return ctrl.Result{RequeueAfter: budgetRequeue}, nil
+			}
+			log.Error(err, "failed to delete pod beyond the last ordinal", "pod", pod.Name)
+			return ctrl.Result{}, client.IgnoreNotFound(err)
+		}
+		log.Info("Deleted pod beyond the last ordinal", "pod", pod.Name)
+		return ctrl.Result{RequeueAfter: time.Second * 5}, nil
+	}
+
+	updatedPods := 0
+	var podToUpdate *corev1.Pod
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if isPodUpToDate(pod, desiredRevision) {
+			updatedPods++
+			continue
+		}
+		if podToUpdate == nil || podOrdinal(myCR, pod) > podOrdinal(myCR, podToUpdate) {
+			podToUpdate = pod
+		}
+	}
+	if podToUpdate == nil || updatedPods >= maxUpdated {
+		return ctrl.Result{}, nil
+	}
+
+	// Pods whose template only differs in resources or pod metadata are updated in place
+	if resizing, result, err := r.syncInPlaceResize(ctx, myCR, podList, desiredRevision, maxUpdated); resizing || err != nil {
+		return result, err
+	}
+	if updating, result, err := r.syncInPlaceMetadata(ctx, myCR, podList, desiredRevision, maxUpdated); updating || err != nil {
+		return result, err
+	}
+
+	// Only one pod is unavailable at a time
+	for i := range podList.Items {
+		if !isPodReady(&podList.Items[i]) {
+			return ctrl.Result{RequeueAfter: time.Second * 5}, nil
+		}
+	}
+
+	// Respect the pod update rate limit before taking another pod down
+	if delay := r.reservePodUpdate(myCR); delay > 0 {
+		log.Info("Pod update rate limit reached", "pod", podToUpdate.Name, "retryAfter", delay)
+		return ctrl.Result{RequeueAfter: delay}, nil
+	}
+
+	if err := r.Delete(ctx, podToUpdate); err != nil {
+		if budgetDenied(err) {
+			log.Info("Disruption budget denied deleting pod, retrying later", "pod", podToUpdate.Name)
+			return ctrl.Result{RequeueAfter: budgetRequeue}, nil
+		}
+		log.Error(err, "failed to delete outdated pod", "pod", podToUpdate.Name)
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	log.Info("Deleted outdated pod to recreate it under the same ordinal", "pod", podToUpdate.Name)
+	return ctrl.Result{RequeueAfter: time.Second * 5}, nil
+}
+
+// podsToCreate returns how many of the missing pods may be created now. With
+// OrderedReady only one pod is created, and only once every other pod is ready.
+func podsToCreate(myCR *appsexamplecomv1alpha1.MiniCloneSet, podList *corev1.PodList, missing int) int {
+	if missing <= 0 || myCR.Spec.PodManagementPolicy != appsexamplecomv1alpha1.OrderedReadyPodManagement {
+		return max(missing, 0)
+	}
+	for i := range podList.Items {
+		if !isPodReady(&podList.Items[i]) {
+			return 0
+		}
+	}
+	return 1
+}
+
+// podsToDelete returns how many of the excess pods may be deleted now. With
+// OrderedReady pods are deleted one at a time; the next one goes once the
+// previous one has terminated.
+func podsToDelete(myCR *appsexamplecomv1alpha1.MiniCloneSet, excess int) int {
+	if excess <= 0 || myCR.Spec.PodManagementPolicy != appsexamplecomv1alpha1.OrderedReadyPodManagement {
+		return max(excess, 0)
+	}
+	return 1
+}
+
+// podOrdinal returns the ordinal a pod is named after, or -1 if it has none
+func podOrdinal(myCR *appsexamplecomv1alpha1.MiniCloneSet, pod *corev1.Pod) int {
+	suffix, ok := strings.CutPrefix(pod.Name, myCR.Name+"-")
+	if !ok {
+		return -1
+	}
+	ordinal, err := strconv.Atoi(suffix)
+	if err != nil {
+		return -1
+	}
+	return ordinal
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+
+	appsexamplecomv1alpha1 "k8s.openkruise.com/v1/api/v1alpha1"
+)
+
+// applyInstanceOverrides applies, in order, the patch of every instance
+// override selecting the pod
+func applyInstanceOverrides(myCR *appsexamplecomv1alpha1.MiniCloneSet, pod *corev1.Pod, index int) error {
+	for _, override := range myCR.Spec.InstanceOverrides {
+		selected, err := selectsInstance(override.Selector, pod.Name, index)
+		if err != nil {
+			return fmt.Errorf("invalid instance override selector %q: %w", override.Selector, err)
+		}
+		if !selected {
+			continue
+		}
+
+		original, err := json.Marshal(pod)
+		if err != nil {
+			return err
+		}
+		patched, err := strategicpatch.StrategicMergePatch(original, override.Patch.Raw, &corev1.Pod{})
+		if err != nil {
+			return fmt.Errorf("invalid patch of instance override %q: %w", override.Selector, err)
+		}
+		*pod = corev1.Pod{}
+		if err := json.Unmarshal(patched, pod); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// selectsInstance checks if a selector, an ordinal ("3"), an ordinal range
+// ("0-2") or a pod name ("web-3"), selects the given pod
+func selectsInstance(selector, podName string, index int) (bool, error) {
+	if selector == podName {
+		return true, nil
+	}
+	if ordinal, err := strconv.Atoi(selector); err == nil {
+		return ordinal == index, nil
+	}
+	if low, high, ok := strings.Cut(selector, "-"); ok {
+		from, errFrom := strconv.Atoi(low)
+		to, errTo := strconv.Atoi(high)
+		if errFrom == nil && errTo == nil {
+			if from > to {
+				return false, fmt.Errorf("range %d-%d is empty", from, to)
+			}
+			return from <= index && index <= to, nil
+		}
+	}
+	// Anything else is the name of a pod that may not exist yet
+	return false, nil
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	appsexamplecomv1alpha1 "k8s.openkruise.com/v1/api/v1alpha1"
+)
+
+// syncDisruptionBudget keeps the PodDisruptionBudget named after the
+// MiniCloneSet in sync with spec.disruptionBudget and the desired replicas,
+// and deletes it once spec.disruptionBudget is cleared. PodDisruptionBudgets
+// the MiniCloneSet does not control are never deleted.
+func (r *MiniCloneSetReconciler) syncDisruptionBudget(ctx context.Context, myCR *appsexamplecomv1alpha1.MiniCloneSet, desiredReplicas int) error {
+	log := logf.FromContext(ctx)
+
+	pdb := &policyv1.PodDisruptionBudget{}
+	if myCR.Spec.DisruptionBudget == nil {
+		if err := r.Get(ctx, types.NamespacedName{Namespace: myCR.Namespace, Name: myCR.Name}, pdb); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+		if !metav1.IsControlledBy(pdb, myCR) {
+			return nil
+		}
+		if err := r.Delete(ctx, pdb); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+		log.Info("Deleted PodDisruptionBudget", "pdb", pdb.Name)
+		return nil
+	}
+
+	minAvailable, err := minAvailablePods(myCR.Spec.DisruptionBudget, desiredReplicas)
+	if err != nil {
+		return err
+	}
+	pdb = &policyv1.PodDisruptionBudget{ObjectMeta: metav1.ObjectMeta{Name: myCR.Name, Namespace: myCR.Namespace}}
+	op, err := controllerutil.CreateOrUpdate(ctx, r.Client, pdb, func() error {
+		if pdb.Labels == nil {
+			pdb.Labels = map[string]string{}
+		}
+		pdb.Labels["app"] = myCR.Name
+		pdb.Spec.Selector = &metav1.LabelSelector{MatchLabels: map[string]string{"app": myCR.Name}}
+		pdb.Spec.MinAvailable = &minAvailable
+		pdb.Spec.MaxUnavailable = nil
+		return ctrl.SetControllerReference(myCR, pdb, r.Scheme)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to sync PodDisruptionBudget: %w", err)
+	}
+	if op != controllerutil.OperationResultNone {
+		log.Info("Synced PodDisruptionBudget", "pdb", pdb.Name, "minAvailable", minAvailable.IntValue(), "operation", op)
+	}
+	return nil
+}
+
+// budgetRequeue is how long to wait before retrying a pod disruption the pod
+// unavailable budget webhook denied
+const budgetRequeue = time.Second * 10
+
+// budgetDenied checks if a pod disruption was denied by the pod unavailable
+// budget webhook. Restarts and image updates of the controller are checked
+// against the budget like any other, so the caller requeues instead of
+// failing the reconcile. Its deletes are only denied when the webhook does
+// not know the controller's service account.
+func budgetDenied(err error) bool {
+	return apierrors.IsForbidden(err)
+}
+
+// minAvailablePods turns a disruption budget into an absolute minAvailable.
+// The eviction API needs the scale of a pod's controller to resolve
+// percentages and maxUnavailable, which a MiniCloneSet does not expose, so
+// the PodDisruptionBudget only ever gets a number of pods.
+func minAvailablePods(budget *appsexamplecomv1alpha1.DisruptionBudget, desiredReplicas int) (intstr.IntOrString, error) {
+	minAvailable, err := budget.MinAvailablePods(desiredReplicas)
+	if err != nil {
+		return intstr.IntOrString{}, err
+	}
+	return intstr.FromInt32(int32(minAvailable)), nil
+}
@@ -0,0 +1,181 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	appsexamplecomv1alpha1 "k8s.openkruise.com/v1/api/v1alpha1"
+)
+
+// syncNodes returns how many pods a PerNode MiniCloneSet should run, one for
+// every matching node, and deletes the pods of nodes that no longer match
+func (r *MiniCloneSetReconciler) syncNodes(ctx context.Context, myCR *appsexamplecomv1alpha1.MiniCloneSet, podList *corev1.PodList) (int, error) {
+	log := logf.FromContext(ctx)
+
+	nodes, err := r.eligibleNodes(ctx, myCR)
+	if err != nil {
+		return 0, err
+	}
+	eligible := map[string]bool{}
+	for _, node := range nodes {
+		eligible[node] = true
+	}
+
+	pods := podList.Items[:0]
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if eligible[pod.Labels[appsexamplecomv1alpha1.NodeLabel]] {
+			pods = append(pods, *pod)
+			continue
+		}
+		if err := r.Delete(ctx, pod); err != nil && !apierrors.IsNotFound(err) {
+			// The pod is retried on a later reconcile, its node gets no replacement
+			if budgetDenied(err) {
+				log.Info("Disruption budget denied deleting pod of ineligible node, retrying later", "pod", pod.Name)
+				continue
+			}
+			log.Error(err, "failed to delete pod of ineligible node", "pod", pod.Name)
+			return 0, err
+		}
+		log.Info("Deleted pod of ineligible node", "pod", pod.Name, "node", pod.Labels[appsexamplecomv1alpha1.NodeLabel])
+	}
+	podList.Items = pods
+	return len(nodes), nil
+}
+
+// eligibleNodes returns the names of the nodes matching the node selector, sorted
+func (r *MiniCloneSetReconciler) eligibleNodes(ctx context.Context, myCR *appsexamplecomv1alpha1.MiniCloneSet) ([]string, error) {
+	var nodeList corev1.NodeList
+	if err := r.List(ctx, &nodeList, client.MatchingLabels(myCR.Spec.NodeSelector)); err != nil {
+		return nil, err
+	}
+	nodes := []string{}
+	for _, node := range nodeList.Items {
+		if node.DeletionTimestamp == nil {
+			nodes = append(nodes, node.Name)
+		}
+	}
+	sort.Strings(nodes)
+	return nodes, nil
+}
+
+// pickNode returns the first node, by name, without any live pod. When every
+// node runs a pod it returns the first node whose only pod is outdated, which
+// is how a rolling update surges the replacement onto the node of the pod it
+// replaces. Pods are listed in node order, so that is the pod replaced next.
+func (r *MiniCloneSetReconciler) pickNode(ctx context.Context, myCR *appsexamplecomv1alpha1.MiniCloneSet, podList *corev1.PodList, revision string) (string, error) {
+	nodes, err := r.eligibleNodes(ctx, myCR)
+	if err != nil {
+		return "", err
+	}
+	pods := map[string]int{}
+	updated := map[string]bool{}
+	for _, pod := range podList.Items {
+		node := pod.Labels[appsexamplecomv1alpha1.NodeLabel]
+		pods[node]++
+		if isPodUpToDate(&pod, revision) {
+			updated[node] = true
+		}
+	}
+	for _, node := range nodes {
+		if pods[node] == 0 {
+			return node, nil
+		}
+	}
+	for _, node := range nodes {
+		if pods[node] == 1 && !updated[node] {
+			return node, nil
+		}
+	}
+	return "", fmt.Errorf("every eligible node already runs a pod of revision %s", revision)
+}
+
+// placeOnNode pins a pod to a node the way DaemonSet pods are
+func placeOnNode(pod *corev1.Pod, node string) {
+	pod.Labels[appsexamplecomv1alpha1.NodeLabel] = node
+	pod.Spec.Affinity = &corev1.Affinity{
+		NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+					MatchFields: []corev1.NodeSelectorRequirement{{
+						Key:      "metadata.name",
+						Operator: corev1.NodeSelectorOpIn,
+						Values:   []string{node},
+					}},
+				}},
+			},
+		},
+	}
+}
+
+// sortByNode orders pods by the node they were created for
+func sortByNode(pods []corev1.Pod) {
+	sort.SliceStable(pods, func(i, j int) bool {
+		return pods[i].Labels[appsexamplecomv1alpha1.NodeLabel] < pods[j].Labels[appsexamplecomv1alpha1.NodeLabel]
+	})
+}
+
+// nodeChanged filters node events down to the ones that can change which
+// nodes a PerNode MiniCloneSet runs on, leaving out status heartbeats
+var nodeChanged = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldNode, ok := e.ObjectOld.(*corev1.Node)
+		if !ok {
+			return false
+		}
+		newNode, ok := e.ObjectNew.(*corev1.Node)
+		if !ok {
+			return false
+		}
+		return !maps.Equal(oldNode.Labels, newNode.Labels) ||
+			!apiequality.Semantic.DeepEqual(oldNode.Spec.Taints, newNode.Spec.Taints) ||
+			oldNode.Spec.Unschedulable != newNode.Spec.Unschedulable ||
+			(oldNode.DeletionTimestamp == nil) != (newNode.DeletionTimestamp == nil)
+	},
+}
+
+// perNodeMiniCloneSets maps a node event to every PerNode MiniCloneSet
+func (r *MiniCloneSetReconciler) perNodeMiniCloneSets(ctx context.Context, _ client.Object) []reconcile.Request {
+	var cloneSets appsexamplecomv1alpha1.MiniCloneSetList
+	if err := r.List(ctx, &cloneSets); err != nil {
+		logf.FromContext(ctx).Error(err, "failed to list MiniCloneSets for node event")
+		return nil
+	}
+	requests := []reconcile.Request{}
+	for _, cloneSet := range cloneSets.Items {
+		if cloneSet.Spec.Mode == appsexamplecomv1alpha1.PerNodeMode {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: cloneSet.Namespace, Name: cloneSet.Name},
+			})
+		}
+	}
+	return requests
+}
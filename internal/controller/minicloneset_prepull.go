@@ -0,0 +1,206 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	appsexamplecomv1alpha1 "k8s.openkruise.com/v1/api/v1alpha1"
+)
+
+// syncImagePrePull pulls the image of the desired revision onto the nodes
+// that ran outdated pods when pulling started, with short-lived puller pods,
+// one per node, and reports whether pods may be replaced: once MinPulled nodes
+// reported the image pulled or the timeout passed. Puller pods of other revisions, and all of
+// them once pulling completed, are deleted.
+func (r *MiniCloneSetReconciler) syncImagePrePull(ctx context.Context, myCR *appsexamplecomv1alpha1.MiniCloneSet, podList *corev1.PodList, desiredRevision string, now time.Time) (bool, error) {
+	log := logf.FromContext(ctx)
+
+	if myCR.Spec.PrePull == nil {
+		return true, nil
+	}
+	status := myCR.Status.ImagePrePull
+	if status == nil || status.Revision != desiredRevision {
+		status = &appsexamplecomv1alpha1.ImagePrePullStatus{Revision: desiredRevision, StartedAt: metav1.NewTime(now)}
+		myCR.Status.ImagePrePull = status
+	}
+	// The nodes are taken once, as replacing pods shrinks the outdated ones
+	if status.NodeNames == nil && !status.Completed {
+		status.NodeNames = prePullNodes(podList, desiredRevision)
+	}
+
+	var allPullers corev1.PodList
+	if err := r.List(ctx, &allPullers, client.InNamespace(myCR.Namespace),
+		client.MatchingLabels{appsexamplecomv1alpha1.PrePullLabel: myCR.Name}); err != nil {
+		return false, err
+	}
+	pullers := map[string]*corev1.Pod{}
+	for i := range allPullers.Items {
+		puller := &allPullers.Items[i]
+		if !metav1.IsControlledBy(puller, myCR) || puller.DeletionTimestamp != nil {
+			continue
+		}
+		if !status.Completed && isPodUpToDate(puller, desiredRevision) {
+			pullers[puller.Spec.NodeName] = puller
+			continue
+		}
+		if err := r.Delete(ctx, puller); client.IgnoreNotFound(err) != nil {
+			return false, err
+		}
+	}
+	if status.Completed {
+		return true, nil
+	}
+
+	status.Nodes, status.Pulled = len(status.NodeNames), 0
+	for _, node := range status.NodeNames {
+		puller, ok := pullers[node]
+		if !ok {
+			puller = newImagePuller(myCR, node, desiredRevision)
+			if err := ctrl.SetControllerReference(myCR, puller, r.Scheme); err != nil {
+				return false, err
+			}
+			if err := r.Create(ctx, puller); err != nil {
+				log.Error(err, "failed to create image puller", "node", node)
+				return false, err
+			}
+			log.Info("Pulling image onto node", "image", myCR.Spec.Image, "node", node)
+			continue
+		}
+		if imagePulled(puller) {
+			status.Pulled++
+		}
+	}
+
+	minPulled := intstr.FromString("100%")
+	if myCR.Spec.PrePull.MinPulled != nil {
+		minPulled = *myCR.Spec.PrePull.MinPulled
+	}
+	required, err := intstr.GetScaledValueFromIntOrPercent(&minPulled, status.Nodes, true)
+	if err != nil {
+		return false, err
+	}
+	timeout := time.Duration(myCR.Spec.PrePull.TimeoutSeconds) * time.Second
+	switch {
+	case status.Pulled >= min(required, status.Nodes):
+		log.Info("Pulled image onto nodes", "image", myCR.Spec.Image, "pulled", status.Pulled, "nodes", status.Nodes)
+	case timeout > 0 && now.Sub(status.StartedAt.Time) >= timeout:
+		log.Info("Timed out pulling image, replacing pods anyway", "image", myCR.Spec.Image, "pulled", status.Pulled, "nodes", status.Nodes)
+	default:
+		return false, nil
+	}
+	status.Completed = true
+	for _, puller := range pullers {
+		if err := r.Delete(ctx, puller); client.IgnoreNotFound(err) != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// prePullNodes returns the sorted nodes running outdated pods, where their
+// replacements are expected to run
+func prePullNodes(podList *corev1.PodList, desiredRevision string) []string {
+	nodes := []string{}
+	for _, pod := range podList.Items {
+		if pod.Spec.NodeName != "" && !isPodUpToDate(&pod, desiredRevision) && !slices.Contains(nodes, pod.Spec.NodeName) {
+			nodes = append(nodes, pod.Spec.NodeName)
+		}
+	}
+	slices.Sort(nodes)
+	return nodes
+}
+
+var (
+	// pullerResources are the requests and limits of an image puller
+	pullerResources = corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("10m"),
+		corev1.ResourceMemory: resource.MustParse("16Mi"),
+	}
+	// pulledWaitingReasons are the reasons a puller container waits with
+	// after its image was pulled
+	pulledWaitingReasons = []string{"CreateContainerError", "RunContainerError"}
+	// pulledTerminatedReasons are the reasons a puller container terminates
+	// with when its image was pulled but it could not run
+	pulledTerminatedReasons = []string{"StartError", "ContainerCannotRun"}
+)
+
+// newImagePuller builds a pod pulling the image of the MiniCloneSet onto a
+// node. It has no app label, so it is never taken for one of the pods.
+func newImagePuller(myCR *appsexamplecomv1alpha1.MiniCloneSet, node, revision string) *corev1.Pod {
+	deadline := int64(max(myCR.Spec.PrePull.TimeoutSeconds, 60))
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: myCR.Name + "-pull-",
+			Namespace:    myCR.Namespace,
+			Labels: map[string]string{
+				appsexamplecomv1alpha1.PrePullLabel:   myCR.Name,
+				appsv1.ControllerRevisionHashLabelKey: revision,
+			},
+		},
+		Spec: corev1.PodSpec{
+			NodeName:              node,
+			RestartPolicy:         corev1.RestartPolicyNever,
+			ActiveDeadlineSeconds: &deadline,
+			Tolerations:           []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+			Containers: []corev1.Container{{
+				Name:            "pull",
+				Image:           myCR.Spec.Image,
+				ImagePullPolicy: corev1.PullIfNotPresent,
+				// Only the pull matters. An image without the command, like a
+				// distroless one, fails to start and still counts as pulled.
+				Command: []string{"true"},
+				// Explicit resources keep the puller admitted under a quota
+				Resources: corev1.ResourceRequirements{
+					Requests: pullerResources,
+					Limits:   pullerResources,
+				},
+			}},
+		},
+	}
+}
+
+// imagePulled checks if the node of an image puller reported its image
+// pulled. The image ID proves it, and so does a container that failed to be
+// created or started, which happens only after the pull. Whether the command
+// ran is ignored; a puller terminates as well when the pull fails and its
+// deadline passes.
+func imagePulled(puller *corev1.Pod) bool {
+	for _, status := range puller.Status.ContainerStatuses {
+		if status.ImageID != "" {
+			return true
+		}
+		if waiting := status.State.Waiting; waiting != nil && slices.Contains(pulledWaitingReasons, waiting.Reason) {
+			return true
+		}
+		if terminated := status.State.Terminated; terminated != nil && slices.Contains(pulledTerminatedReasons, terminated.Reason) {
+			return true
+		}
+	}
+	return false
+}
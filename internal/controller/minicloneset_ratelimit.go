@@ -0,0 +1,84 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	appsexamplecomv1alpha1 "k8s.openkruise.com/v1/api/v1alpha1"
+)
+
+// updateRateLimiters keeps a token bucket per MiniCloneSet that caps how fast
+// outdated pods are replaced. The zero value is ready to use.
+type updateRateLimiters struct {
+	mu       sync.Mutex
+	limiters map[types.NamespacedName]*rate.Limiter
+}
+
+// reserve takes a token for one pod update of the MiniCloneSet. It returns
+// zero when the update may happen now, or how long to wait for the next token.
+func (l *updateRateLimiters) reserve(myCR *appsexamplecomv1alpha1.MiniCloneSet) time.Duration {
+	if myCR.Spec.MaxPodUpdatesPerMinute == nil {
+		l.forget(types.NamespacedName{Namespace: myCR.Namespace, Name: myCR.Name})
+		return 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	key := types.NamespacedName{Namespace: myCR.Namespace, Name: myCR.Name}
+	limit := rate.Limit(float64(*myCR.Spec.MaxPodUpdatesPerMinute) / time.Minute.Seconds())
+	limiter, ok := l.limiters[key]
+	if !ok || limiter.Limit() != limit {
+		if l.limiters == nil {
+			l.limiters = map[types.NamespacedName]*rate.Limiter{}
+		}
+		limiter = rate.NewLimiter(limit, 1)
+		l.limiters[key] = limiter
+	}
+
+	reservation := limiter.Reserve()
+	if delay := reservation.Delay(); delay > 0 {
+		reservation.Cancel()
+		return delay
+	}
+	return 0
+}
+
+// reservePodUpdate takes a token for one pod update of the MiniCloneSet like
+// reserve. While no token is left it records when the next one is due, which
+// holds the rollout so a slow rate limit does not run into the progress
+// deadline.
+func (r *MiniCloneSetReconciler) reservePodUpdate(myCR *appsexamplecomv1alpha1.MiniCloneSet) time.Duration {
+	delay := r.updateLimiters.reserve(myCR)
+	if delay > 0 {
+		myCR.Status.NextPodUpdateTime = &metav1.Time{Time: time.Now().Add(delay)}
+	}
+	return delay
+}
+
+// forget drops the token bucket of a MiniCloneSet
+func (l *updateRateLimiters) forget(key types.NamespacedName) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.limiters, key)
+}
@@ -0,0 +1,264 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	appsexamplecomv1alpha1 "k8s.openkruise.com/v1/api/v1alpha1"
+)
+
+// syncInPlaceResize moves pods whose revision only differs from the desired
+// one in cpu or memory to the desired revision through the resize
+// subresource. One pod is resized at a time; it is relabeled once the kubelet
+// reports the new resources. Pods whose resize is infeasible are left
+// outdated, so the rolling update replaces them within its maxUnavailable
+// budget and the pod update rate limit. It reports whether it acted, in which
+// case the caller must not replace any pod in this reconcile.
+func (r *MiniCloneSetReconciler) syncInPlaceResize(ctx context.Context, myCR *appsexamplecomv1alpha1.MiniCloneSet, podList *corev1.PodList, desiredRevision string, maxUpdated int) (bool, ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	updatedPods := 0
+	var candidate *corev1.Pod
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if isPodUpToDate(pod, desiredRevision) {
+			updatedPods++
+			continue
+		}
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		if resizeInfeasible(pod) {
+			if err := r.abandonResize(ctx, myCR, pod); err != nil {
+				return false, ctrl.Result{}, err
+			}
+			continue
+		}
+		if pod.Annotations[appsexamplecomv1alpha1.ResizingToAnnotation] == desiredRevision {
+			return r.finishResize(ctx, myCR, pod, desiredRevision)
+		}
+		if candidate != nil {
+			continue
+		}
+		inPlace, err := r.onlyResourcesChanged(ctx, myCR, pod)
+		if err != nil {
+			return false, ctrl.Result{}, err
+		}
+		if inPlace {
+			candidate = pod
+		}
+	}
+	if candidate == nil || updatedPods >= maxUpdated {
+		return false, ctrl.Result{}, nil
+	}
+
+	// Respect the pod update rate limit before resizing another pod
+	if delay := r.reservePodUpdate(myCR); delay > 0 {
+		log.Info("Pod update rate limit reached", "pod", candidate.Name, "retryAfter", delay)
+		return true, ctrl.Result{RequeueAfter: delay}, nil
+	}
+
+	desired, err := desiredResources(myCR, candidate)
+	if err != nil {
+		return false, ctrl.Result{}, err
+	}
+	containers := []map[string]any{}
+	for _, container := range candidate.Spec.Containers {
+		if resources, ok := desired[container.Name]; ok {
+			containers = append(containers, map[string]any{"name": container.Name, "resources": resources})
+		}
+	}
+	patch, err := json.Marshal(map[string]any{"spec": map[string]any{"containers": containers}})
+	if err != nil {
+		return false, ctrl.Result{}, err
+	}
+	if err := r.SubResource("resize").Patch(ctx, candidate, client.RawPatch(types.StrategicMergePatchType, patch)); err != nil {
+		// Clusters without in-place resize get the pod replaced instead
+		if apierrors.IsNotFound(err) || apierrors.IsMethodNotSupported(err) {
+			log.Info("In-place resize is not supported, replacing pod", "pod", candidate.Name)
+			return false, ctrl.Result{}, nil
+		}
+		log.Error(err, "failed to resize pod", "pod", candidate.Name)
+		return false, ctrl.Result{}, err
+	}
+
+	// The annotation is written after the resize, so a failure here only
+	// repeats the same resize on the next reconcile
+	annotate := client.MergeFrom(candidate.DeepCopy())
+	if candidate.Annotations == nil {
+		candidate.Annotations = map[string]string{}
+	}
+	candidate.Annotations[appsexamplecomv1alpha1.ResizingToAnnotation] = desiredRevision
+	if err := r.Patch(ctx, candidate, annotate); err != nil {
+		return false, ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	log.Info("Resizing pod in place", "pod", candidate.Name, "revision", desiredRevision)
+	return true, ctrl.Result{RequeueAfter: time.Second * 5}, nil
+}
+
+// finishResize checks on a pod being resized in place. The pod is moved to
+// the desired revision once its containers run with the desired resources.
+// Resized containers are not restarted, so their revision environment
+// variable keeps the old revision, see injectIdentity.
+func (r *MiniCloneSetReconciler) finishResize(ctx context.Context, myCR *appsexamplecomv1alpha1.MiniCloneSet, pod *corev1.Pod, desiredRevision string) (bool, ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	desired, err := desiredResources(myCR, pod)
+	if err != nil {
+		return false, ctrl.Result{}, err
+	}
+	if !resizeDone(pod, desired) {
+		return true, ctrl.Result{RequeueAfter: time.Second * 5}, nil
+	}
+
+	patch := client.MergeFrom(pod.DeepCopy())
+	pod.Labels[appsv1.ControllerRevisionHashLabelKey] = desiredRevision
+	delete(pod.Annotations, appsexamplecomv1alpha1.ResizingToAnnotation)
+	if err := r.Patch(ctx, pod, patch); err != nil {
+		return false, ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	log.Info("Resized pod in place", "pod", pod.Name, "revision", desiredRevision)
+	return true, ctrl.Result{RequeueAfter: time.Second * 5}, nil
+}
+
+// abandonResize drops the resize marker of a pod whose resize the node
+// reported infeasible. The pod keeps its outdated revision and is never picked
+// for an in-place resize again, so the rolling update replaces it like any
+// other outdated pod.
+func (r *MiniCloneSetReconciler) abandonResize(ctx context.Context, myCR *appsexamplecomv1alpha1.MiniCloneSet, pod *corev1.Pod) error {
+	if _, ok := pod.Annotations[appsexamplecomv1alpha1.ResizingToAnnotation]; !ok {
+		return nil
+	}
+	patch := client.MergeFrom(pod.DeepCopy())
+	delete(pod.Annotations, appsexamplecomv1alpha1.ResizingToAnnotation)
+	if err := r.Patch(ctx, pod, patch); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	r.Recorder.Eventf(myCR, corev1.EventTypeWarning, appsexamplecomv1alpha1.ResizeInfeasibleReason,
+		"Pod %s cannot be resized in place, replacing it", pod.Name)
+	logf.FromContext(ctx).Info("In-place resize is infeasible, replacing pod", "pod", pod.Name)
+	return nil
+}
+
+// onlyResourcesChanged checks if the pod's revision differs from the update
+// revision in nothing but the cpu and memory of its resources. Pods whose
+// revision is gone are never resized.
+func (r *MiniCloneSetReconciler) onlyResourcesChanged(ctx context.Context, myCR *appsexamplecomv1alpha1.MiniCloneSet, pod *corev1.Pod) (bool, error) {
+	template, ok, err := r.differsOnlyIn(ctx, myCR, pod, func(t *appsexamplecomv1alpha1.MiniCloneSet) {
+		t.Spec.Resources = corev1.ResourceRequirements{}
+	})
+	if err != nil || !ok {
+		return false, err
+	}
+	return apiequality.Semantic.DeepEqual(withoutResizable(template.Spec.Resources), withoutResizable(myCR.Spec.Resources)), nil
+}
+
+// withoutResizable returns the resources with cpu and memory, the only ones
+// that can be resized in place, left out
+func withoutResizable(resources corev1.ResourceRequirements) corev1.ResourceRequirements {
+	resources = *resources.DeepCopy()
+	for _, list := range []corev1.ResourceList{resources.Requests, resources.Limits} {
+		delete(list, corev1.ResourceCPU)
+		delete(list, corev1.ResourceMemory)
+	}
+	return resources
+}
+
+// desiredResources returns the resources each container of the pod should
+// have under the update revision, with instance overrides applied
+func desiredResources(myCR *appsexamplecomv1alpha1.MiniCloneSet, pod *corev1.Pod) (map[string]corev1.ResourceRequirements, error) {
+	scratch := &corev1.Pod{
+		ObjectMeta: pod.ObjectMeta,
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name:      "main",
+				Image:     myCR.Spec.Image,
+				Resources: *myCR.Spec.Resources.DeepCopy(),
+			}},
+		},
+	}
+	if err := applyInstanceOverrides(myCR, scratch, podOrdinal(myCR, pod)); err != nil {
+		return nil, fmt.Errorf("failed to apply instance overrides to pod %s: %w", pod.Name, err)
+	}
+	desired := map[string]corev1.ResourceRequirements{}
+	for _, container := range scratch.Spec.Containers {
+		desired[container.Name] = container.Resources
+	}
+	return desired, nil
+}
+
+// resizeInfeasible checks if the node reported that the pod's resize can never
+// be admitted
+func resizeInfeasible(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodResizePending && condition.Status == corev1.ConditionTrue &&
+			condition.Reason == corev1.PodReasonInfeasible {
+			return true
+		}
+	}
+	return false
+}
+
+// resizeDone checks if the resize of the pod is no longer pending or in
+// progress and its containers run with the desired resources
+func resizeDone(pod *corev1.Pod, desired map[string]corev1.ResourceRequirements) bool {
+	for _, condition := range pod.Status.Conditions {
+		if (condition.Type == corev1.PodResizePending || condition.Type == corev1.PodResizeInProgress) &&
+			condition.Status == corev1.ConditionTrue {
+			return false
+		}
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		resources, ok := desired[status.Name]
+		if !ok {
+			continue
+		}
+		if status.Resources == nil {
+			return false
+		}
+		for name, quantity := range resources.Requests {
+			if name != corev1.ResourceCPU && name != corev1.ResourceMemory {
+				continue
+			}
+			if actual, ok := status.Resources.Requests[name]; !ok || actual.Cmp(quantity) != 0 {
+				return false
+			}
+		}
+		for name, quantity := range resources.Limits {
+			if name != corev1.ResourceCPU && name != corev1.ResourceMemory {
+				continue
+			}
+			if actual, ok := status.Resources.Limits[name]; !ok || actual.Cmp(quantity) != 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,185 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsexamplecomv1alpha1 "k8s.openkruise.com/v1/api/v1alpha1"
+)
+
+// restarterImage is the image of the ephemeral containers that restart
+// containers in place
+const restarterImage = "busybox:1.36"
+
+// restartCountEnv is set on a restarter to the restart count of its target
+// container when the restarter was added, so the restart can be confirmed
+const restartCountEnv = "TARGET_RESTART_COUNT"
+
+// maxRestarters caps the restarters of a pod. Ephemeral containers can never
+// be removed from a pod, so every in-place restart leaves one behind; pods
+// that reached the cap have to be recreated to be restarted again.
+const maxRestarters = 16
+
+// restartTimeout is how long a container may take to restart after its
+// restarter sent SIGTERM before the restart is considered failed
+const restartTimeout = time.Minute
+
+// restarterName returns the name of the ephemeral container restarting the
+// given container for the given round, e.g. a revision hash
+func restarterName(container, round string) string {
+	name := fmt.Sprintf("%s%s-%s", appsexamplecomv1alpha1.RestarterPrefix, container, round)
+	if len(name) > 63 {
+		name = strings.TrimRight(name[:63], "-")
+	}
+	return name
+}
+
+// restartersLeft returns how many more restarters can be added to the pod
+// before it reaches maxRestarters
+func restartersLeft(pod *corev1.Pod) int {
+	restarters := 0
+	for _, container := range pod.Spec.EphemeralContainers {
+		if strings.HasPrefix(container.Name, appsexamplecomv1alpha1.RestarterPrefix) {
+			restarters++
+		}
+	}
+	return max(maxRestarters-restarters, 0)
+}
+
+// restartInFlight checks if a restarter of the pod has not finished yet, in
+// which case the pod is about to become unavailable even if it is ready
+func restartInFlight(pod *corev1.Pod) bool {
+	for _, container := range pod.Spec.EphemeralContainers {
+		if !strings.HasPrefix(container.Name, appsexamplecomv1alpha1.RestarterPrefix) {
+			continue
+		}
+		if !slices.ContainsFunc(pod.Status.EphemeralContainerStatuses, func(s corev1.ContainerStatus) bool {
+			return s.Name == container.Name && s.State.Terminated != nil
+		}) {
+			return true
+		}
+	}
+	return false
+}
+
+// addRestarters restarts containers of a pod in place, keeping the pod, its IP
+// and its volumes. Each container gets an ephemeral container sharing its
+// process namespace that sends SIGTERM to its main process, so the kubelet
+// starts it again. The main process must handle SIGTERM, as it runs as PID 1
+// of the namespace; restartState fails the restart when it does not.
+// Containers that already have a restarter for the round are skipped. The
+// caller must check restartersLeft first.
+func addRestarters(ctx context.Context, c client.Client, pod *corev1.Pod, containers []string, round string) error {
+	added := false
+	for _, container := range containers {
+		name := restarterName(container, round)
+		if slices.ContainsFunc(pod.Spec.EphemeralContainers, func(e corev1.EphemeralContainer) bool { return e.Name == name }) {
+			continue
+		}
+		pod.Spec.EphemeralContainers = append(pod.Spec.EphemeralContainers, corev1.EphemeralContainer{
+			TargetContainerName: container,
+			EphemeralContainerCommon: corev1.EphemeralContainerCommon{
+				Name:    name,
+				Image:   restarterImage,
+				Command: []string{"kill", "-TERM", "1"},
+				Env: []corev1.EnvVar{{
+					Name:  restartCountEnv,
+					Value: strconv.Itoa(int(restartCount(pod, container))),
+				}},
+			},
+		})
+		added = true
+	}
+	if !added {
+		return nil
+	}
+	return c.SubResource("ephemeralcontainers").Update(ctx, pod)
+}
+
+// restartState reports whether a container was restarted by its restarter of
+// the given round and runs ready again, or whether the restart failed. A
+// restart only counts once the restart count of the container went up, so a
+// restarter that exits cleanly without the container restarting, e.g.
+// because its main process ignores SIGTERM, fails after restartTimeout.
+func restartState(pod *corev1.Pod, container, round string, now time.Time) (done, failed bool) {
+	name := restarterName(container, round)
+	index := slices.IndexFunc(pod.Spec.EphemeralContainers, func(e corev1.EphemeralContainer) bool { return e.Name == name })
+	if index < 0 {
+		return false, false
+	}
+	baseline := int32(-1)
+	for _, env := range pod.Spec.EphemeralContainers[index].Env {
+		if env.Name == restartCountEnv {
+			if count, err := strconv.Atoi(env.Value); err == nil {
+				baseline = int32(count)
+			}
+		}
+	}
+
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == container && baseline >= 0 && status.RestartCount > baseline {
+			return status.Ready && status.State.Running != nil, false
+		}
+	}
+
+	for _, status := range pod.Status.EphemeralContainerStatuses {
+		if status.Name != name {
+			continue
+		}
+		if waiting := status.State.Waiting; waiting != nil && restarterStuck(waiting.Reason) {
+			return false, true
+		}
+		if terminated := status.State.Terminated; terminated != nil {
+			// Restarters that could not signal the container, e.g. without
+			// CAP_KILL, exit with an error
+			if terminated.ExitCode != 0 || baseline < 0 {
+				return false, true
+			}
+			return false, now.After(terminated.FinishedAt.Add(restartTimeout))
+		}
+	}
+	return false, false
+}
+
+// restarterStuck checks if a restarter waits for a reason that will not go
+// away on its own
+func restarterStuck(reason string) bool {
+	switch reason {
+	case "CreateContainerConfigError", "CreateContainerError", "ErrImagePull", "ImagePullBackOff", "InvalidImageName":
+		return true
+	}
+	return false
+}
+
+// restartCount returns the restart count of a container of the pod
+func restartCount(pod *corev1.Pod, container string) int32 {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == container {
+			return status.RestartCount
+		}
+	}
+	return 0
+}
@@ -0,0 +1,295 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"sort"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/rand"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	appsexamplecomv1alpha1 "k8s.openkruise.com/v1/api/v1alpha1"
+)
+
+// revisionHistoryLimit is the number of old ControllerRevisions kept for rollback
+const revisionHistoryLimit = 10
+
+// getPatch returns a merge patch holding the fields of the MiniCloneSet spec
+// that make up the pod template. Applying it to the MiniCloneSet restores
+// that template, which is how rollbacks are performed.
+func getPatch(myCR *appsexamplecomv1alpha1.MiniCloneSet) ([]byte, error) {
+	spec := map[string]interface{}{
+		"image": myCR.Spec.Image,
+	}
+	// Optional fields are left out when unset so existing revisions keep their hash
+	if !apiequality.Semantic.DeepEqual(myCR.Spec.Resources, corev1.ResourceRequirements{}) {
+		spec["resources"] = myCR.Spec.Resources
+	}
+	if len(myCR.Spec.Env) > 0 {
+		spec["env"] = myCR.Spec.Env
+	}
+	if myCR.Spec.PodMetadata != nil {
+		spec["podMetadata"] = myCR.Spec.PodMetadata
+	}
+	if len(myCR.Spec.InstanceOverrides) > 0 {
+		spec["instanceOverrides"] = myCR.Spec.InstanceOverrides
+	}
+	return json.Marshal(map[string]interface{}{
+		"spec": spec,
+	})
+}
+
+// optionalTemplateFields are the template fields getPatch leaves out when unset
+var optionalTemplateFields = []string{"resources", "env", "podMetadata", "instanceOverrides"}
+
+// restorePatch turns the data of a revision into a merge patch that restores
+// its template exactly, clearing optional fields the revision did not set
+func restorePatch(data []byte) ([]byte, error) {
+	var patch struct {
+		Spec map[string]interface{} `json:"spec"`
+	}
+	if err := json.Unmarshal(data, &patch); err != nil {
+		return nil, err
+	}
+	if patch.Spec == nil {
+		patch.Spec = map[string]interface{}{}
+	}
+	for _, field := range optionalTemplateFields {
+		if _, ok := patch.Spec[field]; !ok {
+			patch.Spec[field] = nil
+		}
+	}
+	return json.Marshal(patch)
+}
+
+// computeHash returns a stable, label-safe hash of the given patch. A
+// collision count is mixed in once two templates have collided.
+func computeHash(patch []byte, collisionCount *int32) string {
+	hasher := fnv.New32a()
+	hasher.Write(patch)
+	if collisionCount != nil {
+		collisionCountBytes := make([]byte, 8)
+		binary.LittleEndian.PutUint32(collisionCountBytes, uint32(*collisionCount))
+		hasher.Write(collisionCountBytes)
+	}
+	return rand.SafeEncodeString(fmt.Sprint(hasher.Sum32()))
+}
+
+// sameTemplate reports whether two revision patches hold the same template
+func sameTemplate(a, b []byte) bool {
+	var left, right interface{}
+	if json.Unmarshal(a, &left) != nil || json.Unmarshal(b, &right) != nil {
+		return false
+	}
+	return apiequality.Semantic.DeepEqual(left, right)
+}
+
+// revisionName returns the ControllerRevision name for the given hash
+func revisionName(myCR *appsexamplecomv1alpha1.MiniCloneSet, hash string) string {
+	return fmt.Sprintf("%s-%s", myCR.Name, hash)
+}
+
+// syncRevision makes sure a ControllerRevision exists for the current pod
+// template, prunes old revisions and returns the hash of the current one
+func (r *MiniCloneSetReconciler) syncRevision(ctx context.Context, myCR *appsexamplecomv1alpha1.MiniCloneSet) (string, error) {
+	log := logf.FromContext(ctx)
+
+	patch, err := getPatch(myCR)
+	if err != nil {
+		return "", err
+	}
+
+	revisions, err := r.listRevisions(ctx, myCR)
+	if err != nil {
+		return "", err
+	}
+
+	var nextRevision int64 = 1
+	for _, revision := range revisions {
+		if revision.Revision >= nextRevision {
+			nextRevision = revision.Revision + 1
+		}
+	}
+
+	// A revision named after the hash that holds another template is a hash
+	// collision, which is resolved by bumping status.collisionCount
+	hash := computeHash(patch, myCR.Status.CollisionCount)
+	for {
+		existing := slices.IndexFunc(revisions, func(revision appsv1.ControllerRevision) bool {
+			return revision.Name == revisionName(myCR, hash)
+		})
+		if existing < 0 {
+			break
+		}
+		if sameTemplate(revisions[existing].Data.Raw, patch) {
+			return hash, nil
+		}
+		collisionCount := int32(1)
+		if myCR.Status.CollisionCount != nil {
+			collisionCount = *myCR.Status.CollisionCount + 1
+		}
+		myCR.Status.CollisionCount = &collisionCount
+		log.Info("Revision hash collision", "revision", revisions[existing].Name, "collisionCount", collisionCount)
+		hash = computeHash(patch, myCR.Status.CollisionCount)
+	}
+
+	revision := &appsv1.ControllerRevision{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      revisionName(myCR, hash),
+			Namespace: myCR.Namespace,
+			Labels: map[string]string{
+				"app":                                 myCR.Name,
+				appsv1.ControllerRevisionHashLabelKey: hash,
+			},
+		},
+		Data:     runtime.RawExtension{Raw: patch},
+		Revision: nextRevision,
+	}
+	if err := ctrl.SetControllerReference(myCR, revision, r.Scheme); err != nil {
+		return "", err
+	}
+	if err := r.Create(ctx, revision); err != nil {
+		if !apierrors.IsAlreadyExists(err) {
+			log.Error(err, "failed to create ControllerRevision", "revision", revision.Name)
+			return "", err
+		}
+		// The revision exists but was not listed, for example because the
+		// cache is behind. Only reuse it if it holds the same template, the
+		// next reconcile resolves a collision once the cache has caught up.
+		existing := &appsv1.ControllerRevision{}
+		if err := r.Get(ctx, client.ObjectKeyFromObject(revision), existing); err != nil {
+			return "", err
+		}
+		if !metav1.IsControlledBy(existing, myCR) || !sameTemplate(existing.Data.Raw, patch) {
+			return "", fmt.Errorf("ControllerRevision %s already exists and holds another template", revision.Name)
+		}
+		return hash, nil
+	}
+	log.Info("Created ControllerRevision", "revision", revision.Name, "number", nextRevision)
+
+	revisions = append(revisions, *revision)
+	if err := r.truncateHistory(ctx, myCR, revisions, hash); err != nil {
+		return "", err
+	}
+	return hash, nil
+}
+
+// listRevisions returns the ControllerRevisions owned by the MiniCloneSet, oldest first
+func (r *MiniCloneSetReconciler) listRevisions(ctx context.Context, myCR *appsexamplecomv1alpha1.MiniCloneSet) ([]appsv1.ControllerRevision, error) {
+	var revisionList appsv1.ControllerRevisionList
+	if err := r.List(ctx, &revisionList, client.InNamespace(myCR.Namespace), client.MatchingLabels{"app": myCR.Name}); err != nil {
+		return nil, err
+	}
+
+	revisions := []appsv1.ControllerRevision{}
+	for _, revision := range revisionList.Items {
+		if metav1.IsControlledBy(&revision, myCR) {
+			revisions = append(revisions, revision)
+		}
+	}
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].Revision < revisions[j].Revision
+	})
+	return revisions, nil
+}
+
+// truncateHistory deletes the oldest revisions beyond revisionHistoryLimit,
+// never touching the current, the update or the hibernated revision
+func (r *MiniCloneSetReconciler) truncateHistory(ctx context.Context, myCR *appsexamplecomv1alpha1.MiniCloneSet, revisions []appsv1.ControllerRevision, updateRevision string) error {
+	excess := len(revisions) - revisionHistoryLimit
+	for i := 0; i < len(revisions) && excess > 0; i++ {
+		hash := revisions[i].Labels[appsv1.ControllerRevisionHashLabelKey]
+		if hash == updateRevision || hash == myCR.Status.CurrentRevision || isHibernatedRevision(myCR, hash) {
+			continue
+		}
+		if err := r.Delete(ctx, &revisions[i]); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+		excess--
+	}
+	return nil
+}
+
+// templateForRevision returns a copy of the MiniCloneSet whose pod template is
+// the one recorded in the given revision
+func (r *MiniCloneSetReconciler) templateForRevision(ctx context.Context, myCR *appsexamplecomv1alpha1.MiniCloneSet, hash string) (*appsexamplecomv1alpha1.MiniCloneSet, error) {
+	if hash == myCR.Status.UpdateRevision {
+		return myCR, nil
+	}
+	var revision appsv1.ControllerRevision
+	if err := r.Get(ctx, types.NamespacedName{Namespace: myCR.Namespace, Name: revisionName(myCR, hash)}, &revision); err != nil {
+		return nil, err
+	}
+	patch, err := restorePatch(revision.Data.Raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid data in revision %s: %w", revision.Name, err)
+	}
+	template := myCR.DeepCopy()
+	if err := json.Unmarshal(patch, template); err != nil {
+		return nil, fmt.Errorf("invalid data in revision %s: %w", revision.Name, err)
+	}
+	return template, nil
+}
+
+// differsOnlyIn checks if the template of the pod's revision differs from the
+// current one, and only in the fields reset by clear. It also returns the
+// template of the pod's revision. Pods whose revision is gone never qualify.
+func (r *MiniCloneSetReconciler) differsOnlyIn(ctx context.Context, myCR *appsexamplecomv1alpha1.MiniCloneSet, pod *corev1.Pod, clear func(*appsexamplecomv1alpha1.MiniCloneSet)) (*appsexamplecomv1alpha1.MiniCloneSet, bool, error) {
+	template, err := r.templateForRevision(ctx, myCR, pod.Labels[appsv1.ControllerRevisionHashLabelKey])
+	if err != nil {
+		return nil, false, client.IgnoreNotFound(err)
+	}
+	oldPatch, err := getPatch(template)
+	if err != nil {
+		return nil, false, err
+	}
+	currentPatch, err := getPatch(myCR)
+	if err != nil {
+		return nil, false, err
+	}
+	if bytes.Equal(oldPatch, currentPatch) {
+		return template, false, nil
+	}
+
+	old := template.DeepCopy()
+	clear(old)
+	current := myCR.DeepCopy()
+	clear(current)
+	if oldPatch, err = getPatch(old); err != nil {
+		return nil, false, err
+	}
+	if currentPatch, err = getPatch(current); err != nil {
+		return nil, false, err
+	}
+	return template, bytes.Equal(oldPatch, currentPatch), nil
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	appsexamplecomv1alpha1 "k8s.openkruise.com/v1/api/v1alpha1"
+)
+
+// autoRollback reverts the MiniCloneSet to its current revision when the
+// rollout to the update revision is failing and autoRollback is enabled.
+// It reports whether a rollback was performed.
+func (r *MiniCloneSetReconciler) autoRollback(ctx context.Context, myCR *appsexamplecomv1alpha1.MiniCloneSet, podList *corev1.PodList) (bool, error) {
+	log := logf.FromContext(ctx)
+
+	policy := myCR.Spec.AutoRollback
+	fromRevision := myCR.Status.UpdateRevision
+	toRevision := myCR.Status.CurrentRevision
+	if policy == nil || toRevision == "" || toRevision == fromRevision {
+		return false, nil
+	}
+
+	var reason, message string
+	failedPods := countFailedPods(podList, fromRevision, time.Duration(policy.WindowSeconds)*time.Second, time.Now())
+	switch {
+	case failedPods > policy.MaxFailedPods:
+		reason = appsexamplecomv1alpha1.PodsFailedReason
+		message = fmt.Sprintf("%d updated pods failed, more than the %d allowed", failedPods, policy.MaxFailedPods)
+	case isProgressDeadlineExceeded(myCR):
+		reason = appsexamplecomv1alpha1.ProgressDeadlineExceededReason
+		message = "rollout exceeded its progress deadline"
+	default:
+		return false, nil
+	}
+
+	var revision appsv1.ControllerRevision
+	if err := r.Get(ctx, types.NamespacedName{Namespace: myCR.Namespace, Name: revisionName(myCR, toRevision)}, &revision); err != nil {
+		log.Error(err, "failed to fetch revision to roll back to", "revision", toRevision)
+		return false, client.IgnoreNotFound(err)
+	}
+
+	// Restore the template of the last fully available revision
+	patch, err := restorePatch(revision.Data.Raw)
+	if err != nil {
+		log.Error(err, "invalid data in revision to roll back to", "revision", toRevision)
+		return false, err
+	}
+	if err := r.Patch(ctx, myCR, client.RawPatch(types.MergePatchType, patch)); err != nil {
+		log.Error(err, "failed to roll back MiniCloneSet", "revision", toRevision)
+		return false, err
+	}
+
+	myCR.Status.LastRollback = &appsexamplecomv1alpha1.RollbackStatus{
+		FromRevision: fromRevision,
+		ToRevision:   toRevision,
+		Reason:       reason,
+		Message:      message,
+		Time:         metav1.Now(),
+	}
+	if err := r.Status().Update(ctx, myCR); err != nil {
+		log.Error(err, "failed to record rollback in MiniCloneSet status")
+		return true, err
+	}
+
+	log.Info("Rolled back MiniCloneSet", "from", fromRevision, "to", toRevision, "reason", reason)
+	r.Recorder.Eventf(myCR, corev1.EventTypeWarning, appsexamplecomv1alpha1.RolledBackReason,
+		"Rolled back from revision %s to %s: %s", fromRevision, toRevision, message)
+	return true, nil
+}
+
+// countFailedPods counts the pods of the given revision that are crash-looping,
+// cannot pull their image, or have not become ready within window
+func countFailedPods(podList *corev1.PodList, revision string, window time.Duration, now time.Time) int {
+	failed := 0
+	for _, pod := range podList.Items {
+		if isPodUpToDate(&pod, revision) && isPodFailed(&pod, window, now) {
+			failed++
+		}
+	}
+	return failed
+}
+
+// isPodFailed checks if a pod has failed or is not expected to become ready
+func isPodFailed(pod *corev1.Pod, window time.Duration, now time.Time) bool {
+	if pod.Status.Phase == corev1.PodFailed {
+		return true
+	}
+	if isPodReady(pod) {
+		return false
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.State.Waiting == nil {
+			continue
+		}
+		switch status.State.Waiting.Reason {
+		case "CrashLoopBackOff", "ErrImagePull", "ImagePullBackOff", "InvalidImageName":
+			return true
+		}
+	}
+	return now.Sub(pod.CreationTimestamp.Time) > window
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appsexamplecomv1alpha1 "k8s.openkruise.com/v1/api/v1alpha1"
+)
+
+// scaleScheduleLookback bounds how far back the controller looks for the
+// scale schedule entry that fired last
+const scaleScheduleLookback = 7 * 24 * time.Hour
+
+// syncScaleSchedule returns the replica count to work towards at now and how
+// long until the next scale schedule entry fires. When an entry has fired
+// since the last reconcile it is recorded in status.
+func (r *MiniCloneSetReconciler) syncScaleSchedule(myCR *appsexamplecomv1alpha1.MiniCloneSet, now time.Time) (int, time.Duration, error) {
+	status := &myCR.Status
+	if len(myCR.Spec.ScaleSchedule) == 0 {
+		status.ActiveScaleSchedule = nil
+		return myCR.Spec.Replicas, 0, nil
+	}
+
+	// Nothing older than the entry already in effect can be the latest one
+	from := now.Add(-scaleScheduleLookback)
+	if active := status.ActiveScaleSchedule; active != nil && active.ActivatedAt.After(from) {
+		from = active.ActivatedAt.Add(-time.Nanosecond)
+	}
+
+	var latest *appsexamplecomv1alpha1.ScaleScheduleStatus
+	var nextFire time.Time
+	for i, entry := range myCR.Spec.ScaleSchedule {
+		schedule, location, err := parseSchedule(entry.Schedule, entry.TimeZone)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid scale schedule entry %d: %w", i, err)
+		}
+
+		last := lastFiring(schedule, from.In(location), now.In(location))
+		if !last.IsZero() && (latest == nil || last.After(latest.ActivatedAt.Time)) {
+			latest = &appsexamplecomv1alpha1.ScaleScheduleStatus{
+				Index:       i,
+				Schedule:    entry.Schedule,
+				Replicas:    entry.Replicas,
+				ActivatedAt: metav1.NewTime(last),
+			}
+		}
+		if next := schedule.Next(now.In(location)); nextFire.IsZero() || next.Before(nextFire) {
+			nextFire = next
+		}
+	}
+	untilNext := nextFire.Sub(now)
+
+	if latest != nil && !sameScaleScheduleActivation(status.ActiveScaleSchedule, latest) {
+		latest.SpecReplicas = myCR.Spec.Replicas
+		status.ActiveScaleSchedule = latest
+		r.Recorder.Eventf(myCR, corev1.EventTypeNormal, appsexamplecomv1alpha1.ScheduledScaleReason,
+			"Scale schedule %q set replicas to %d", latest.Schedule, latest.Replicas)
+	}
+
+	// A manual edit of replicas wins until the next entry fires
+	active := status.ActiveScaleSchedule
+	if active == nil || active.SpecReplicas != myCR.Spec.Replicas {
+		return myCR.Spec.Replicas, untilNext, nil
+	}
+	return active.Replicas, untilNext, nil
+}
+
+// sameScaleScheduleActivation checks if two statuses describe the same firing of the same entry
+func sameScaleScheduleActivation(a, b *appsexamplecomv1alpha1.ScaleScheduleStatus) bool {
+	return a != nil && b != nil &&
+		a.Index == b.Index && a.Schedule == b.Schedule && a.ActivatedAt.Equal(&b.ActivatedAt)
+}
+
+// lastFiring returns the last time the schedule fired after from and up to
+// now, or the zero time if it did not. Windows ending at now that double in
+// size are scanned in turn, so a schedule firing every minute costs a handful
+// of steps instead of one per firing over the whole lookback.
+func lastFiring(schedule cron.Schedule, from, now time.Time) time.Time {
+	for window := time.Minute; ; window *= 2 {
+		start := now.Add(-window)
+		if !start.After(from) {
+			start = from
+		}
+		var last time.Time
+		for t := schedule.Next(start); !t.After(now); t = schedule.Next(t) {
+			last = t
+		}
+		if !last.IsZero() || start.Equal(from) {
+			return last
+		}
+	}
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	appsexamplecomv1alpha1 "k8s.openkruise.com/v1/api/v1alpha1"
+)
+
+// syncService keeps the Service named after the MiniCloneSet in sync with
+// spec.service, and deletes it once spec.service is cleared. Services the
+// MiniCloneSet does not control are never adopted, changed or deleted; a
+// warning event is recorded instead.
+func (r *MiniCloneSetReconciler) syncService(ctx context.Context, myCR *appsexamplecomv1alpha1.MiniCloneSet) error {
+	log := logf.FromContext(ctx)
+
+	svc := &corev1.Service{}
+	err := r.Get(ctx, types.NamespacedName{Namespace: myCR.Namespace, Name: myCR.Name}, svc)
+	if client.IgnoreNotFound(err) != nil {
+		return err
+	}
+	owned := err == nil && metav1.IsControlledBy(svc, myCR)
+
+	// The cluster IP of a Service cannot be changed, so switching between a
+	// headless and a regular Service recreates it
+	if owned && (myCR.Spec.Service == nil || (svc.Spec.ClusterIP == corev1.ClusterIPNone) != myCR.Spec.Service.Headless) {
+		if err := r.Delete(ctx, svc); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+		log.Info("Deleted Service", "service", svc.Name)
+		return nil
+	}
+	if myCR.Spec.Service == nil {
+		return nil
+	}
+	if err == nil && !owned {
+		r.Recorder.Eventf(myCR, corev1.EventTypeWarning, appsexamplecomv1alpha1.ServiceConflictReason,
+			"Service %s already exists and is not controlled by the MiniCloneSet, delete or rename it", svc.Name)
+		log.Info("Service exists and is not controlled by the MiniCloneSet, leaving it alone", "service", svc.Name)
+		return nil
+	}
+
+	svc = &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: myCR.Name, Namespace: myCR.Namespace}}
+	op, err := controllerutil.CreateOrUpdate(ctx, r.Client, svc, func() error {
+		setService(myCR, svc)
+		return ctrl.SetControllerReference(myCR, svc, r.Scheme)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to sync Service: %w", err)
+	}
+	if op != controllerutil.OperationResultNone {
+		log.Info("Synced Service", "service", svc.Name, "operation", op)
+	}
+	return nil
+}
+
+// setService sets the fields of a Service from spec.service. It selects the
+// pods of the MiniCloneSet, only the active ones with the BlueGreen strategy,
+// and keeps node ports already allocated to a port.
+func setService(myCR *appsexamplecomv1alpha1.MiniCloneSet, svc *corev1.Service) {
+	spec := myCR.Spec.Service
+	if svc.Labels == nil {
+		svc.Labels = map[string]string{}
+	}
+	svc.Labels["app"] = myCR.Name
+
+	svc.Spec.Type = spec.Type
+	if svc.Spec.Type == "" {
+		svc.Spec.Type = corev1.ServiceTypeClusterIP
+	}
+	if spec.Headless {
+		svc.Spec.ClusterIP = corev1.ClusterIPNone
+	}
+	svc.Spec.Selector = map[string]string{"app": myCR.Name}
+	if myCR.Spec.UpdateStrategy == appsexamplecomv1alpha1.BlueGreenStrategyType {
+		svc.Spec.Selector[appsexamplecomv1alpha1.ActiveLabel] = "true"
+	}
+
+	ports := spec.Ports
+	if len(ports) == 0 {
+		ports = []appsexamplecomv1alpha1.ServicePort{{Name: "http", Port: 80}}
+	}
+	servicePorts := make([]corev1.ServicePort, 0, len(ports))
+	for _, port := range ports {
+		servicePort := corev1.ServicePort{
+			Name:       port.Name,
+			Port:       port.Port,
+			Protocol:   port.Protocol,
+			TargetPort: intstr.FromInt32(port.Port),
+		}
+		if servicePort.Protocol == "" {
+			servicePort.Protocol = corev1.ProtocolTCP
+		}
+		if port.TargetPort != nil {
+			servicePort.TargetPort = *port.TargetPort
+		}
+		if svc.Spec.Type != corev1.ServiceTypeClusterIP {
+			for _, existing := range svc.Spec.Ports {
+				if existing.Port == servicePort.Port && existing.Protocol == servicePort.Protocol {
+					servicePort.NodePort = existing.NodePort
+				}
+			}
+		}
+		servicePorts = append(servicePorts, servicePort)
+	}
+	svc.Spec.Ports = servicePorts
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"math"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	appsexamplecomv1alpha1 "k8s.openkruise.com/v1/api/v1alpha1"
+)
+
+// pickSubset returns the first spread subset, in priority order, that has
+// room for another pod, or nil when there is no spread or every subset is full
+func pickSubset(myCR *appsexamplecomv1alpha1.MiniCloneSet, podList *corev1.PodList) (*appsexamplecomv1alpha1.SpreadSubset, error) {
+	if myCR.Spec.Spread == nil {
+		return nil, nil
+	}
+
+	counts := map[string]int{}
+	for _, pod := range podList.Items {
+		counts[pod.Labels[appsexamplecomv1alpha1.SubsetLabel]]++
+	}
+	for i := range myCR.Spec.Spread.Subsets {
+		subset := &myCR.Spec.Spread.Subsets[i]
+		if subset.MaxReplicas == nil {
+			return subset, nil
+		}
+		maxReplicas, err := intstr.GetScaledValueFromIntOrPercent(subset.MaxReplicas, myCR.Status.DesiredReplicas, true)
+		if err != nil {
+			return nil, err
+		}
+		if counts[subset.Name] < maxReplicas {
+			return subset, nil
+		}
+	}
+	return nil, nil
+}
+
+// placeInSubset restricts a pod to the nodes of a spread subset
+func placeInSubset(pod *corev1.Pod, subset *appsexamplecomv1alpha1.SpreadSubset) {
+	pod.Labels[appsexamplecomv1alpha1.SubsetLabel] = subset.Name
+	pod.Spec.Affinity = &corev1.Affinity{
+		NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{*subset.RequiredNodeSelectorTerm.DeepCopy()},
+			},
+		},
+	}
+	for _, toleration := range subset.Tolerations {
+		pod.Spec.Tolerations = append(pod.Spec.Tolerations, *toleration.DeepCopy())
+	}
+}
+
+// sortForScaleDown returns a copy of pods in the order they should be removed.
+// With stable identity the highest ordinals go first. Otherwise pods of
+// subsets above their maxReplicas go first, then pods outside any subset and
+// pods of the lowest priority subsets. Within a subset unready and outdated
+// pods go first.
+func sortForScaleDown(myCR *appsexamplecomv1alpha1.MiniCloneSet, pods []corev1.Pod, desiredRevision string) []corev1.Pod {
+	remaining := append([]corev1.Pod{}, pods...)
+	if myCR.Spec.StableIdentity {
+		sort.SliceStable(remaining, func(i, j int) bool {
+			return podOrdinal(myCR, &remaining[i]) > podOrdinal(myCR, &remaining[j])
+		})
+		return remaining
+	}
+	sort.SliceStable(remaining, func(i, j int) bool {
+		return deletionCost(&remaining[i], desiredRevision) < deletionCost(&remaining[j], desiredRevision)
+	})
+	if myCR.Spec.Spread == nil {
+		return remaining
+	}
+
+	counts := map[string]int{}
+	for _, pod := range remaining {
+		counts[pod.Labels[appsexamplecomv1alpha1.SubsetLabel]]++
+	}
+	limits := map[string]int{}
+	for _, subset := range myCR.Spec.Spread.Subsets {
+		if subset.MaxReplicas == nil {
+			continue
+		}
+		if limit, err := intstr.GetScaledValueFromIntOrPercent(subset.MaxReplicas, myCR.Status.DesiredReplicas, true); err == nil {
+			limits[subset.Name] = limit
+		}
+	}
+	overLimit := func(pod *corev1.Pod) bool {
+		name := pod.Labels[appsexamplecomv1alpha1.SubsetLabel]
+		limit, ok := limits[name]
+		return ok && counts[name] > limit
+	}
+
+	// Counts change with every pick, so choose one pod at a time
+	sorted := make([]corev1.Pod, 0, len(remaining))
+	for len(remaining) > 0 {
+		next := 0
+		for i := 1; i < len(remaining); i++ {
+			oi, on := overLimit(&remaining[i]), overLimit(&remaining[next])
+			if oi != on {
+				if oi {
+					next = i
+				}
+				continue
+			}
+			if subsetPriority(myCR, &remaining[i]) < subsetPriority(myCR, &remaining[next]) {
+				next = i
+			}
+		}
+		counts[remaining[next].Labels[appsexamplecomv1alpha1.SubsetLabel]]--
+		sorted = append(sorted, remaining[next])
+		remaining = append(remaining[:next], remaining[next+1:]...)
+	}
+	return sorted
+}
+
+// subsetPriority ranks a pod by the priority of its spread subset. Higher
+// priority subsets rank higher; pods outside every subset rank lowest.
+func subsetPriority(myCR *appsexamplecomv1alpha1.MiniCloneSet, pod *corev1.Pod) int {
+	if myCR.Spec.Spread == nil {
+		return 0
+	}
+	name, ok := pod.Labels[appsexamplecomv1alpha1.SubsetLabel]
+	if !ok {
+		return math.MinInt
+	}
+	subsets := myCR.Spec.Spread.Subsets
+	for i := range subsets {
+		if subsets[i].Name == name {
+			return len(subsets) - i
+		}
+	}
+	return math.MinInt
+}
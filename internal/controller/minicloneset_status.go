@@ -0,0 +1,182 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	appsexamplecomv1alpha1 "k8s.openkruise.com/v1/api/v1alpha1"
+)
+
+// updateStatus records the observed pods in status and tracks rollout progress
+// against progressDeadlineSeconds. The returned result requeues no later than
+// the moment the deadline would expire.
+func (r *MiniCloneSetReconciler) updateStatus(ctx context.Context, myCR *appsexamplecomv1alpha1.MiniCloneSet, podList *corev1.PodList, result ctrl.Result) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+	now := metav1.Now()
+
+	status := &myCR.Status
+	readyPods, updatedPods, updatedReadyPods := 0, 0, 0
+	for _, pod := range podList.Items {
+		ready := isPodReady(&pod)
+		if ready {
+			readyPods++
+		}
+		if isPodUpToDate(&pod, status.UpdateRevision) {
+			updatedPods++
+			if ready {
+				updatedReadyPods++
+			}
+		}
+	}
+
+	// A new generation starts the progress clock, and every newly created or
+	// newly ready updated pod restarts it. A rollout on hold is not stuck.
+	holdReason, holdMessage := rolloutHold(myCR)
+	if status.LastProgressTime == nil || holdReason != "" ||
+		status.ObservedGeneration != myCR.Generation ||
+		updatedPods > status.UpdatedReplicas ||
+		updatedReadyPods > status.UpdatedReadyReplicas {
+		status.LastProgressTime = &now
+	}
+	status.ObservedGeneration = myCR.Generation
+	status.AvailableReplicas = readyPods
+	status.UpdatedReplicas = updatedPods
+	status.UpdatedReadyReplicas = updatedReadyPods
+
+	deadlineExceeded := false
+	// Old pods kept around after a blue/green switch do not hold the rollout back
+	switched := myCR.Spec.UpdateStrategy == appsexamplecomv1alpha1.BlueGreenStrategyType &&
+		status.ActiveRevision == status.UpdateRevision
+	complete := status.Hibernation == nil && (len(podList.Items) == status.DesiredReplicas || switched) && updatedReadyPods == status.DesiredReplicas
+	deadline := progressDeadline(myCR)
+
+	switch {
+	case complete:
+		status.CurrentRevision = status.UpdateRevision
+		meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type:               appsexamplecomv1alpha1.ProgressingCondition,
+			Status:             metav1.ConditionTrue,
+			Reason:             appsexamplecomv1alpha1.NewRevisionAvailableReason,
+			Message:            fmt.Sprintf("%d/%d pods are running revision %s and ready", updatedReadyPods, status.DesiredReplicas, status.UpdateRevision),
+			ObservedGeneration: myCR.Generation,
+		})
+	case holdReason != "":
+		meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type:               appsexamplecomv1alpha1.ProgressingCondition,
+			Status:             metav1.ConditionTrue,
+			Reason:             holdReason,
+			Message:            fmt.Sprintf("%s with %d/%d updated pods ready", holdMessage, updatedReadyPods, status.DesiredReplicas),
+			ObservedGeneration: myCR.Generation,
+		})
+	case deadline > 0 && now.Sub(status.LastProgressTime.Time) > deadline:
+		deadlineExceeded = !isProgressDeadlineExceeded(myCR)
+		meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type:               appsexamplecomv1alpha1.ProgressingCondition,
+			Status:             metav1.ConditionFalse,
+			Reason:             appsexamplecomv1alpha1.ProgressDeadlineExceededReason,
+			Message:            fmt.Sprintf("MiniCloneSet %q has timed out progressing: %d/%d updated pods are ready", myCR.Name, updatedReadyPods, status.DesiredReplicas),
+			ObservedGeneration: myCR.Generation,
+		})
+	default:
+		meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type:               appsexamplecomv1alpha1.ProgressingCondition,
+			Status:             metav1.ConditionTrue,
+			Reason:             appsexamplecomv1alpha1.RolloutInProgressReason,
+			Message:            fmt.Sprintf("%d/%d updated pods are ready", updatedReadyPods, status.DesiredReplicas),
+			ObservedGeneration: myCR.Generation,
+		})
+		if deadline > 0 {
+			// Come back when the deadline expires in case nothing else triggers a reconcile
+			result = requeueNoLaterThan(result, status.LastProgressTime.Add(deadline).Sub(now.Time))
+		}
+	}
+
+	if err := r.Status().Update(ctx, myCR); err != nil {
+		log.Error(err, "failed to update MiniCloneSet status")
+		return ctrl.Result{}, err
+	}
+
+	if deadlineExceeded {
+		log.Info("Rollout exceeded its progress deadline", "deadline", deadline)
+		r.Recorder.Eventf(myCR, corev1.EventTypeWarning, appsexamplecomv1alpha1.ProgressDeadlineExceededReason,
+			"MiniCloneSet %q has timed out progressing after %s", myCR.Name, deadline)
+	}
+
+	return result, nil
+}
+
+// rolloutHold returns the reason and message when the rollout is deliberately
+// held back, or empty strings when it is free to progress
+func rolloutHold(myCR *appsexamplecomv1alpha1.MiniCloneSet) (string, string) {
+	status := &myCR.Status
+	switch {
+	case status.Hibernation != nil:
+		return appsexamplecomv1alpha1.HibernatedReason,
+			fmt.Sprintf("Hibernated, %d pods of revision %s are restored on wake up", status.Hibernation.Replicas, status.Hibernation.Revision)
+	case awaitingApproval(myCR):
+		return appsexamplecomv1alpha1.AwaitingApprovalReason,
+			fmt.Sprintf("Waiting for revision %s to be approved", status.UpdateRevision)
+	case status.NextWindowStartTime != nil && status.CurrentRevision != status.UpdateRevision:
+		return appsexamplecomv1alpha1.OutsideUpdateWindowReason,
+			fmt.Sprintf("Waiting for the update window opening at %s", status.NextWindowStartTime.UTC().Format(time.RFC3339))
+	case status.ImagePrePull != nil && status.ImagePrePull.Revision == status.UpdateRevision && !status.ImagePrePull.Completed &&
+		status.CurrentRevision != status.UpdateRevision:
+		return appsexamplecomv1alpha1.PullingImageReason,
+			fmt.Sprintf("Pulling image %s, %d of %d nodes done", myCR.Spec.Image, status.ImagePrePull.Pulled, status.ImagePrePull.Nodes)
+	case status.NextPodUpdateTime != nil && status.CurrentRevision != status.UpdateRevision:
+		return appsexamplecomv1alpha1.RateLimitedReason,
+			fmt.Sprintf("Waiting for the pod update rate limit until %s", status.NextPodUpdateTime.UTC().Format(time.RFC3339))
+	case status.CurrentStepState == appsexamplecomv1alpha1.CanaryStepStatePaused:
+		return appsexamplecomv1alpha1.RolloutPausedReason,
+			fmt.Sprintf("Paused at canary step %d", *status.CurrentStepIndex)
+	}
+	return "", ""
+}
+
+// progressDeadline returns the configured progress deadline, or zero if none is set
+func progressDeadline(myCR *appsexamplecomv1alpha1.MiniCloneSet) time.Duration {
+	if myCR.Spec.ProgressDeadlineSeconds == nil {
+		return 0
+	}
+	return time.Duration(*myCR.Spec.ProgressDeadlineSeconds) * time.Second
+}
+
+// isProgressDeadlineExceeded checks if the Progressing condition already reports a timed out rollout
+func isProgressDeadlineExceeded(myCR *appsexamplecomv1alpha1.MiniCloneSet) bool {
+	cond := meta.FindStatusCondition(myCR.Status.Conditions, appsexamplecomv1alpha1.ProgressingCondition)
+	return cond != nil && cond.Reason == appsexamplecomv1alpha1.ProgressDeadlineExceededReason
+}
+
+// requeueNoLaterThan shortens the requeue delay of result to at most after
+func requeueNoLaterThan(result ctrl.Result, after time.Duration) ctrl.Result {
+	if after <= 0 {
+		after = time.Second
+	}
+	if result.RequeueAfter == 0 || after < result.RequeueAfter {
+		result.RequeueAfter = after
+	}
+	return result
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	appsexamplecomv1alpha1 "k8s.openkruise.com/v1/api/v1alpha1"
+)
+
+// defaultTeardownMaxUnavailable is used when spec.maxUnavailable is unset
+var defaultTeardownMaxUnavailable = intstr.FromString("25%")
+
+// teardown scales a deleted MiniCloneSet down to zero instead of leaving its
+// pods to garbage collection, which would delete all of them at once. At
+// most maxUnavailable pods are taken down at a time, pods with a pending
+// PreDelete hook are marked PreparingDelete and kept until the hook labels
+// are removed, and the teardown finalizer is released once no pod is left.
+func (r *MiniCloneSetReconciler) teardown(ctx context.Context, myCR *appsexamplecomv1alpha1.MiniCloneSet) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	if !controllerutil.ContainsFinalizer(myCR, appsexamplecomv1alpha1.TeardownFinalizer) {
+		return ctrl.Result{}, nil
+	}
+
+	podList, terminating, err := r.listPods(ctx, myCR)
+	if err != nil {
+		log.Error(err, "failed to list pods")
+		return ctrl.Result{}, err
+	}
+	if len(podList.Items) == 0 && terminating == 0 {
+		log.Info("Every pod is gone, releasing the teardown finalizer")
+		controllerutil.RemoveFinalizer(myCR, appsexamplecomv1alpha1.TeardownFinalizer)
+		return ctrl.Result{}, client.IgnoreNotFound(r.Update(ctx, myCR))
+	}
+
+	// Terminating pods and pods waiting for their PreDelete hook are already
+	// unavailable and count against the batch
+	batch, err := teardownBatchSize(myCR, len(podList.Items)+terminating)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	budget := batch - terminating
+	for i := range podList.Items {
+		if preparingDelete(&podList.Items[i]) {
+			budget--
+		}
+	}
+
+	deleted, waiting := 0, 0
+	pods := sortForScaleDown(myCR, podList.Items, myCR.Status.UpdateRevision)
+	for i := range pods {
+		pod := &pods[i]
+		if !preparingDelete(pod) {
+			if budget <= 0 {
+				continue
+			}
+			budget--
+		}
+		if preDeleteHookPending(myCR, pod) {
+			if err := r.markPreparingDelete(ctx, pod); err != nil {
+				// A pod that is already gone frees its place in the batch
+				if apierrors.IsNotFound(err) {
+					continue
+				}
+				log.Error(err, "failed to mark pod PreparingDelete", "pod", pod.Name)
+				return ctrl.Result{}, err
+			}
+			waiting++
+			continue
+		}
+		if err := r.Delete(ctx, pod); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			log.Error(err, "failed to delete pod", "pod", pod.Name)
+			return ctrl.Result{}, err
+		}
+		log.Info("Deleted pod of deleted MiniCloneSet", "pod", pod.Name)
+		deleted++
+	}
+
+	if deleted > 0 {
+		r.Recorder.Eventf(myCR, corev1.EventTypeNormal, appsexamplecomv1alpha1.TearingDownReason,
+			"Deleted %d pods, %d left", deleted, len(pods)-deleted)
+	}
+	if waiting > 0 {
+		log.Info("Waiting for PreDelete hooks", "pods", waiting)
+	}
+	return ctrl.Result{RequeueAfter: time.Second * 5}, nil
+}
+
+// teardownBatchSize returns how many pods may be down at once during
+// teardown. Percentages are of the desired replicas, or of the pods still
+// around if there are more of them, and at least one pod is taken down.
+func teardownBatchSize(myCR *appsexamplecomv1alpha1.MiniCloneSet, pods int) (int, error) {
+	maxUnavailable := myCR.Spec.MaxUnavailable
+	if maxUnavailable == nil {
+		maxUnavailable = &defaultTeardownMaxUnavailable
+	}
+	batch, err := intstr.GetScaledValueFromIntOrPercent(maxUnavailable, max(myCR.Status.DesiredReplicas, pods), true)
+	if err != nil {
+		return 0, fmt.Errorf("invalid maxUnavailable: %w", err)
+	}
+	return max(batch, 1), nil
+}
+
+// addPreDeleteLabels adds the PreDelete hook labels to a new pod. Labels the
+// controller relies on are never overwritten.
+func addPreDeleteLabels(myCR *appsexamplecomv1alpha1.MiniCloneSet, pod *corev1.Pod) {
+	if myCR.Spec.PreDelete == nil {
+		return
+	}
+	for key, value := range myCR.Spec.PreDelete.LabelsHandler {
+		if !reservedMetadataKey(key) {
+			pod.Labels[key] = value
+		}
+	}
+}
+
+// preDeleteHookPending reports whether the pod still carries a PreDelete hook label
+func preDeleteHookPending(myCR *appsexamplecomv1alpha1.MiniCloneSet, pod *corev1.Pod) bool {
+	if myCR.Spec.PreDelete == nil {
+		return false
+	}
+	for key := range myCR.Spec.PreDelete.LabelsHandler {
+		if _, ok := pod.Labels[key]; ok && !reservedMetadataKey(key) {
+			return true
+		}
+	}
+	return false
+}
+
+func preparingDelete(pod *corev1.Pod) bool {
+	return pod.Labels[appsexamplecomv1alpha1.LifecycleStateLabel] == appsexamplecomv1alpha1.PreparingDeleteState
+}
+
+// markPreparingDelete tells the PreDelete hook handlers that the pod is about to be deleted
+func (r *MiniCloneSetReconciler) markPreparingDelete(ctx context.Context, pod *corev1.Pod) error {
+	if preparingDelete(pod) {
+		return nil
+	}
+	patch := client.MergeFrom(pod.DeepCopy())
+	pod.Labels[appsexamplecomv1alpha1.LifecycleStateLabel] = appsexamplecomv1alpha1.PreparingDeleteState
+	return r.Patch(ctx, pod, patch)
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	appsexamplecomv1alpha1 "k8s.openkruise.com/v1/api/v1alpha1"
+)
+
+// updateWindowOpen checks if pods may be updated at now. When every allowed
+// window is closed it also returns when the next one opens.
+func updateWindowOpen(windows []appsexamplecomv1alpha1.UpdateWindow, now time.Time) (bool, time.Time, error) {
+	if len(windows) == 0 {
+		return true, time.Time{}, nil
+	}
+
+	var nextStart time.Time
+	for _, window := range windows {
+		schedule, location, err := parseSchedule(window.Schedule, window.TimeZone)
+		if err != nil {
+			return false, time.Time{}, fmt.Errorf("invalid update window: %w", err)
+		}
+
+		// The first start after now-duration is either the window we are in or the next one
+		duration := time.Duration(window.DurationSeconds) * time.Second
+		start := schedule.Next(now.In(location).Add(-duration))
+		if !start.After(now) {
+			return true, time.Time{}, nil
+		}
+		if nextStart.IsZero() || start.Before(nextStart) {
+			nextStart = start
+		}
+	}
+	return false, nextStart, nil
+}
+
+// parseSchedule parses a standard cron expression and the time zone it is evaluated in
+func parseSchedule(spec string, timeZone *string) (cron.Schedule, *time.Location, error) {
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid schedule %q: %w", spec, err)
+	}
+	location := time.UTC
+	if timeZone != nil {
+		if location, err = time.LoadLocation(*timeZone); err != nil {
+			return nil, nil, fmt.Errorf("invalid time zone %q: %w", *timeZone, err)
+		}
+	}
+	return schedule, location, nil
+}
@@ -0,0 +1,289 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	appsexamplecomv1alpha1 "k8s.openkruise.com/v1/api/v1alpha1"
+)
+
+// MiniSidecarSetReconciler reconciles a MiniSidecarSet object
+type MiniSidecarSetReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=apps.example.com.my.domain,resources=minisidecarsets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=apps.example.com.my.domain,resources=minisidecarsets/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=apps.example.com.my.domain,resources=miniclonesets,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch;patch
+
+// Reconcile rolls the sidecar images of a MiniSidecarSet out in place to the
+// pods of every MiniCloneSet it selects. New pods get the sidecars injected
+// by the MiniCloneSet controller when they are created.
+func (r *MiniSidecarSetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	var sidecarSet appsexamplecomv1alpha1.MiniSidecarSet
+	if err := r.Get(ctx, req.NamespacedName, &sidecarSet); err != nil {
+		log.Error(err, "unable to fetch MiniSidecarSet")
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	selector, err := sidecarSetSelector(&sidecarSet)
+	if err != nil {
+		log.Error(err, "invalid MiniSidecarSet selector")
+		return ctrl.Result{}, err
+	}
+	var cloneSets appsexamplecomv1alpha1.MiniCloneSetList
+	if err := r.List(ctx, &cloneSets, client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		log.Error(err, "failed to list MiniCloneSets")
+		return ctrl.Result{}, err
+	}
+
+	var pods []corev1.Pod
+	for i := range cloneSets.Items {
+		var podList corev1.PodList
+		if err := r.List(ctx, &podList, client.InNamespace(cloneSets.Items[i].Namespace), client.MatchingLabels{"app": cloneSets.Items[i].Name}); err != nil {
+			log.Error(err, "failed to list pods", "minicloneset", cloneSets.Items[i].Name)
+			return ctrl.Result{}, err
+		}
+		for _, pod := range podList.Items {
+			if metav1.IsControlledBy(&pod, &cloneSets.Items[i]) && pod.DeletionTimestamp == nil {
+				pods = append(pods, pod)
+			}
+		}
+	}
+
+	// Pods whose sidecars were patched count as unavailable until the kubelet
+	// runs the new images, which happens before the pod stops being ready
+	outdated := []corev1.Pod{}
+	updated, updatedReady, unavailable := 0, 0, 0
+	for i := range pods {
+		pod := &pods[i]
+		if !sidecarsUpToDate(&sidecarSet, pod) {
+			outdated = append(outdated, *pod)
+			continue
+		}
+		updated++
+		if _, updating := pod.Annotations[appsexamplecomv1alpha1.SidecarUpdatingAnnotation]; updating {
+			if !sidecarsRunning(&sidecarSet, pod) {
+				unavailable++
+				continue
+			}
+			if err := r.finishSidecarUpdate(ctx, pod); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+		if isPodReady(pod) {
+			updatedReady++
+		} else {
+			unavailable++
+		}
+	}
+
+	var result ctrl.Result
+	for i := 0; i < len(outdated) && unavailable < maxSidecarUnavailable(&sidecarSet); i++ {
+		if err := r.updateSidecars(ctx, &sidecarSet, &outdated[i]); err != nil {
+			// The disruption budget of the pod's MiniCloneSet is exhausted, try the next one
+			if budgetDenied(err) {
+				log.Info("Disruption budget denied updating sidecars, retrying later", "pod", outdated[i].Name, "namespace", outdated[i].Namespace)
+				continue
+			}
+			return ctrl.Result{}, err
+		}
+		log.Info("Updated sidecars in place", "pod", outdated[i].Name, "namespace", outdated[i].Namespace)
+		unavailable++
+		updated++
+	}
+	if len(outdated) > 0 || unavailable > 0 {
+		// Pod events move the rollout along, this only guards against missed ones
+		result = ctrl.Result{RequeueAfter: time.Second * 30}
+	}
+
+	sidecarSet.Status.ObservedGeneration = sidecarSet.Generation
+	sidecarSet.Status.MatchedMiniCloneSets = len(cloneSets.Items)
+	sidecarSet.Status.MatchedPods = len(pods)
+	sidecarSet.Status.UpdatedPods = updated
+	sidecarSet.Status.UpdatedReadyPods = updatedReady
+	if err := r.Status().Update(ctx, &sidecarSet); err != nil {
+		log.Error(err, "failed to update MiniSidecarSet status")
+		return ctrl.Result{}, err
+	}
+	return result, nil
+}
+
+// sidecarsUpToDate checks if every sidecar container the pod runs has the
+// image of the MiniSidecarSet. Pods created before the MiniSidecarSet existed
+// do not run its sidecars and cannot get them in place, so they never count
+// as outdated.
+func sidecarsUpToDate(sidecarSet *appsexamplecomv1alpha1.MiniSidecarSet, pod *corev1.Pod) bool {
+	for _, sidecar := range sidecarSet.Spec.Containers {
+		for _, container := range pod.Spec.Containers {
+			if container.Name == sidecar.Name && container.Image != sidecar.Image {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// sidecarsRunning checks if every sidecar container of the pod runs the image
+// of the MiniSidecarSet and is ready
+func sidecarsRunning(sidecarSet *appsexamplecomv1alpha1.MiniSidecarSet, pod *corev1.Pod) bool {
+	for _, sidecar := range sidecarSet.Spec.Containers {
+		if !slices.ContainsFunc(pod.Spec.Containers, func(c corev1.Container) bool { return c.Name == sidecar.Name }) {
+			continue
+		}
+		running := slices.ContainsFunc(pod.Status.ContainerStatuses, func(status corev1.ContainerStatus) bool {
+			return status.Name == sidecar.Name && status.Ready && sameImage(status.Image, sidecar.Image)
+		})
+		if !running {
+			return false
+		}
+	}
+	return true
+}
+
+// sameImage checks if the image reported in a container status is the image
+// of the spec. The kubelet may report it fully qualified, such as
+// docker.io/library/nginx:1.21 for nginx:1.21.
+func sameImage(statusImage, specImage string) bool {
+	return statusImage == specImage || strings.HasSuffix(statusImage, "/"+specImage)
+}
+
+// finishSidecarUpdate removes the sidecar-updating annotation once the new
+// sidecars are running
+func (r *MiniSidecarSetReconciler) finishSidecarUpdate(ctx context.Context, pod *corev1.Pod) error {
+	patch := client.MergeFrom(pod.DeepCopy())
+	delete(pod.Annotations, appsexamplecomv1alpha1.SidecarUpdatingAnnotation)
+	if err := r.Patch(ctx, pod, patch); err != nil && !apierrors.IsNotFound(err) {
+		logf.FromContext(ctx).Error(err, "failed to finish sidecar update", "pod", pod.Name, "namespace", pod.Namespace)
+		return err
+	}
+	return nil
+}
+
+// updateSidecars patches the sidecar images of a pod, which the kubelet
+// applies by restarting just those containers. The pod is annotated as
+// updating until the new sidecars run.
+func (r *MiniSidecarSetReconciler) updateSidecars(ctx context.Context, sidecarSet *appsexamplecomv1alpha1.MiniSidecarSet, pod *corev1.Pod) error {
+	patch := client.MergeFrom(pod.DeepCopy())
+	if pod.Annotations == nil {
+		pod.Annotations = map[string]string{}
+	}
+	pod.Annotations[appsexamplecomv1alpha1.SidecarUpdatingAnnotation] = sidecarSet.Name
+	for _, sidecar := range sidecarSet.Spec.Containers {
+		for i := range pod.Spec.Containers {
+			if pod.Spec.Containers[i].Name == sidecar.Name {
+				pod.Spec.Containers[i].Image = sidecar.Image
+			}
+		}
+	}
+	if err := r.Patch(ctx, pod, patch); err != nil && !apierrors.IsNotFound(err) {
+		logf.FromContext(ctx).Error(err, "failed to update sidecars", "pod", pod.Name, "namespace", pod.Namespace)
+		return err
+	}
+	return nil
+}
+
+// maxSidecarUnavailable returns how many pods may have their sidecars updating at once
+func maxSidecarUnavailable(sidecarSet *appsexamplecomv1alpha1.MiniSidecarSet) int {
+	if sidecarSet.Spec.MaxUnavailable < 1 {
+		return 1
+	}
+	return int(sidecarSet.Spec.MaxUnavailable)
+}
+
+// matchingSidecars returns the sidecar containers of every MiniSidecarSet
+// selecting the MiniCloneSet, ordered by MiniSidecarSet name
+func matchingSidecars(sidecarSets []appsexamplecomv1alpha1.MiniSidecarSet, myCR *appsexamplecomv1alpha1.MiniCloneSet) ([]corev1.Container, error) {
+	var sidecars []corev1.Container
+	for _, sidecarSet := range sidecarSets {
+		selector, err := sidecarSetSelector(&sidecarSet)
+		if err != nil {
+			return nil, err
+		}
+		if !selector.Matches(labels.Set(myCR.Labels)) {
+			continue
+		}
+		sidecars = append(sidecars, sidecarSet.Spec.Containers...)
+	}
+	return sidecars, nil
+}
+
+// sidecarSetSelector returns the MiniCloneSet selector of a MiniSidecarSet. An
+// empty selector selects nothing, so a sidecar cannot end up in every pod by mistake.
+func sidecarSetSelector(sidecarSet *appsexamplecomv1alpha1.MiniSidecarSet) (labels.Selector, error) {
+	selector, err := metav1.LabelSelectorAsSelector(sidecarSet.Spec.Selector)
+	if err != nil || selector.Empty() {
+		return labels.Nothing(), err
+	}
+	return selector, nil
+}
+
+// sidecarSetsForPod maps a pod of a MiniCloneSet to the MiniSidecarSets
+// selecting that MiniCloneSet
+func (r *MiniSidecarSetReconciler) sidecarSetsForPod(ctx context.Context, obj client.Object) []reconcile.Request {
+	owner := metav1.GetControllerOf(obj)
+	if owner == nil || owner.Kind != "MiniCloneSet" || owner.APIVersion != appsexamplecomv1alpha1.GroupVersion.String() {
+		return nil
+	}
+	var cloneSet appsexamplecomv1alpha1.MiniCloneSet
+	if err := r.Get(ctx, types.NamespacedName{Namespace: obj.GetNamespace(), Name: owner.Name}, &cloneSet); err != nil {
+		return nil
+	}
+	var sidecarSets appsexamplecomv1alpha1.MiniSidecarSetList
+	if err := r.List(ctx, &sidecarSets); err != nil {
+		logf.FromContext(ctx).Error(err, "failed to list MiniSidecarSets for pod event")
+		return nil
+	}
+	requests := []reconcile.Request{}
+	for _, sidecarSet := range sidecarSets.Items {
+		selector, err := sidecarSetSelector(&sidecarSet)
+		if err != nil || !selector.Matches(labels.Set(cloneSet.Labels)) {
+			continue
+		}
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: sidecarSet.Name}})
+	}
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *MiniSidecarSetReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&appsexamplecomv1alpha1.MiniSidecarSet{}).
+		Watches(&corev1.Pod{}, handler.EnqueueRequestsFromMapFunc(r.sidecarSetsForPod)).
+		Named("minisidecarset").
+		Complete(r)
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	appsexamplecomv1alpha1 "k8s.openkruise.com/v1/api/v1alpha1"
+)
+
+var _ = Describe("MiniSidecarSet Controller", func() {
+	sidecarSet := appsexamplecomv1alpha1.MiniSidecarSet{
+		Spec: appsexamplecomv1alpha1.MiniSidecarSetSpec{
+			Selector:   &metav1.LabelSelector{MatchLabels: map[string]string{"team": "payments"}},
+			Containers: []corev1.Container{{Name: "log-shipper", Image: "fluent-bit:3.1"}},
+		},
+	}
+
+	It("should only select MiniCloneSets matching its selector", func() {
+		myCR := &appsexamplecomv1alpha1.MiniCloneSet{}
+		myCR.Labels = map[string]string{"team": "payments"}
+		sidecars, err := matchingSidecars([]appsexamplecomv1alpha1.MiniSidecarSet{sidecarSet}, myCR)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sidecars).To(HaveLen(1))
+
+		myCR.Labels = map[string]string{"team": "search"}
+		sidecars, err = matchingSidecars([]appsexamplecomv1alpha1.MiniSidecarSet{sidecarSet}, myCR)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sidecars).To(BeEmpty())
+	})
+
+	It("should not select anything with an empty selector", func() {
+		empty := *sidecarSet.DeepCopy()
+		empty.Spec.Selector = &metav1.LabelSelector{}
+		myCR := &appsexamplecomv1alpha1.MiniCloneSet{}
+		myCR.Labels = map[string]string{"team": "payments"}
+		sidecars, err := matchingSidecars([]appsexamplecomv1alpha1.MiniSidecarSet{empty}, myCR)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sidecars).To(BeEmpty())
+	})
+
+	It("should detect pods running an outdated sidecar image", func() {
+		pod := &corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{
+			{Name: "main", Image: "nginx:1.20"},
+			{Name: "log-shipper", Image: "fluent-bit:3.0"},
+		}}}
+		Expect(sidecarsUpToDate(&sidecarSet, pod)).To(BeFalse())
+
+		pod.Spec.Containers[1].Image = "fluent-bit:3.1"
+		Expect(sidecarsUpToDate(&sidecarSet, pod)).To(BeTrue())
+
+		// Pods without the sidecar cannot get it in place
+		pod.Spec.Containers = pod.Spec.Containers[:1]
+		Expect(sidecarsUpToDate(&sidecarSet, pod)).To(BeTrue())
+	})
+
+	It("should count pods whose sidecar patch is in flight as unavailable", func() {
+		ctx := context.Background()
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(appsexamplecomv1alpha1.AddToScheme(scheme)).To(Succeed())
+		rollout := sidecarSet.DeepCopy()
+		rollout.Name = "logging"
+		rollout.Spec.MaxUnavailable = 1
+		myCR := &appsexamplecomv1alpha1.MiniCloneSet{ObjectMeta: metav1.ObjectMeta{
+			Name: "web", Namespace: "default", UID: "web", Labels: map[string]string{"team": "payments"},
+		}}
+		builder := fake.NewClientBuilder().WithScheme(scheme).WithObjects(rollout, myCR).
+			WithStatusSubresource(rollout, &corev1.Pod{})
+		for i := range 3 {
+			builder = builder.WithObjects(&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            fmt.Sprintf("web-%d", i),
+					Namespace:       "default",
+					Labels:          map[string]string{"app": "web"},
+					OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(myCR, appsexamplecomv1alpha1.GroupVersion.WithKind("MiniCloneSet"))},
+				},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "log-shipper", Image: "fluent-bit:3.0"}}},
+				Status: corev1.PodStatus{
+					Conditions:        []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+					ContainerStatuses: []corev1.ContainerStatus{{Name: "log-shipper", Image: "fluent-bit:3.0", Ready: true}},
+				},
+			})
+		}
+		r := &MiniSidecarSetReconciler{Client: builder.Build(), Scheme: scheme}
+		request := reconcile.Request{NamespacedName: types.NamespacedName{Name: "logging"}}
+		updating := func() []string {
+			var pods corev1.PodList
+			Expect(r.List(ctx, &pods)).To(Succeed())
+			var names []string
+			for _, pod := range pods.Items {
+				if _, ok := pod.Annotations[appsexamplecomv1alpha1.SidecarUpdatingAnnotation]; ok {
+					names = append(names, pod.Name)
+				}
+			}
+			return names
+		}
+
+		Expect(r.Reconcile(ctx, request)).Error().NotTo(HaveOccurred())
+		Expect(updating()).To(Equal([]string{"web-0"}))
+
+		By("waiting while the patched pod is still ready on the old image")
+		Expect(r.Reconcile(ctx, request)).Error().NotTo(HaveOccurred())
+		Expect(updating()).To(Equal([]string{"web-0"}))
+
+		By("moving on once the new sidecar runs")
+		pod := &corev1.Pod{}
+		Expect(r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "web-0"}, pod)).To(Succeed())
+		pod.Status.ContainerStatuses[0].Image = "docker.io/library/fluent-bit:3.1"
+		Expect(r.Status().Update(ctx, pod)).To(Succeed())
+		Expect(r.Reconcile(ctx, request)).Error().NotTo(HaveOccurred())
+		Expect(updating()).To(Equal([]string{"web-1"}))
+
+		Expect(r.sidecarSetsForPod(ctx, pod)).To(Equal([]reconcile.Request{request}))
+	})
+})
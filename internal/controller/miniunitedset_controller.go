@@ -0,0 +1,235 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	appsexamplecomv1alpha1 "k8s.openkruise.com/v1/api/v1alpha1"
+)
+
+// MiniUnitedSetReconciler reconciles a MiniUnitedSet object
+type MiniUnitedSetReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=apps.example.com.my.domain,resources=miniunitedsets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=apps.example.com.my.domain,resources=miniunitedsets/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=apps.example.com.my.domain,resources=miniunitedsets/finalizers,verbs=update
+// +kubebuilder:rbac:groups=apps.example.com.my.domain,resources=miniclonesets,verbs=get;list;watch;create;update;patch;delete
+
+// Reconcile keeps one MiniCloneSet per pool of the MiniUnitedSet in sync with
+// its template and the pool's share of the replicas. A template change is
+// rolled out by every pool's MiniCloneSet with its own update strategy.
+func (r *MiniUnitedSetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	var unitedSet appsexamplecomv1alpha1.MiniUnitedSet
+	if err := r.Get(ctx, req.NamespacedName, &unitedSet); err != nil {
+		log.Error(err, "unable to fetch MiniUnitedSet")
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	replicas, err := poolReplicas(&unitedSet)
+	if err != nil {
+		log.Error(err, "failed to divide replicas over pools")
+		return ctrl.Result{}, err
+	}
+
+	status := appsexamplecomv1alpha1.MiniUnitedSetStatus{ObservedGeneration: unitedSet.Generation}
+	pools := map[string]bool{}
+	for i := range unitedSet.Spec.Pools {
+		pool := &unitedSet.Spec.Pools[i]
+		pools[pool.Name] = true
+
+		cloneSet := &appsexamplecomv1alpha1.MiniCloneSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      poolMiniCloneSetName(&unitedSet, pool),
+				Namespace: unitedSet.Namespace,
+			},
+		}
+		op, err := controllerutil.CreateOrUpdate(ctx, r.Client, cloneSet, func() error {
+			if err := setPoolMiniCloneSet(&unitedSet, pool, replicas[i], cloneSet); err != nil {
+				return err
+			}
+			return ctrl.SetControllerReference(&unitedSet, cloneSet, r.Scheme)
+		})
+		if err != nil {
+			log.Error(err, "failed to sync MiniCloneSet for pool", "pool", pool.Name)
+			return ctrl.Result{}, err
+		}
+		if op != controllerutil.OperationResultNone {
+			log.Info("Synced MiniCloneSet for pool", "pool", pool.Name, "minicloneset", cloneSet.Name, "operation", op)
+		}
+
+		status.AvailableReplicas += cloneSet.Status.AvailableReplicas
+		status.UpdatedReadyReplicas += cloneSet.Status.UpdatedReadyReplicas
+		status.Pools = append(status.Pools, appsexamplecomv1alpha1.PoolStatus{
+			Name:                 pool.Name,
+			Replicas:             replicas[i],
+			AvailableReplicas:    cloneSet.Status.AvailableReplicas,
+			UpdatedReadyReplicas: cloneSet.Status.UpdatedReadyReplicas,
+		})
+	}
+
+	// Remove the MiniCloneSets of pools that were taken out of the spec
+	var cloneSets appsexamplecomv1alpha1.MiniCloneSetList
+	if err := r.List(ctx, &cloneSets, client.InNamespace(unitedSet.Namespace),
+		client.MatchingLabels{appsexamplecomv1alpha1.UnitedSetLabel: unitedSet.Name}); err != nil {
+		log.Error(err, "failed to list MiniCloneSets")
+		return ctrl.Result{}, err
+	}
+	for i := range cloneSets.Items {
+		cloneSet := &cloneSets.Items[i]
+		if !metav1.IsControlledBy(cloneSet, &unitedSet) || pools[cloneSet.Labels[appsexamplecomv1alpha1.PoolLabel]] {
+			continue
+		}
+		if err := r.Delete(ctx, cloneSet); client.IgnoreNotFound(err) != nil {
+			log.Error(err, "failed to delete MiniCloneSet of removed pool", "minicloneset", cloneSet.Name)
+			return ctrl.Result{}, err
+		}
+		log.Info("Deleted MiniCloneSet of removed pool", "minicloneset", cloneSet.Name)
+	}
+
+	unitedSet.Status = status
+	if err := r.Status().Update(ctx, &unitedSet); err != nil {
+		log.Error(err, "failed to update MiniUnitedSet status")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// poolReplicas divides the replicas of a MiniUnitedSet over its pools, in
+// pool order. Pools with a fixed share get it first, as far as replicas are
+// left, and the rest is divided evenly over the other pools. When every pool
+// has a fixed share, pods left over go to the last pool.
+func poolReplicas(unitedSet *appsexamplecomv1alpha1.MiniUnitedSet) ([]int, error) {
+	pools := unitedSet.Spec.Pools
+	replicas := make([]int, len(pools))
+	remaining := unitedSet.Spec.Replicas
+
+	flexible := []int{}
+	for i := range pools {
+		if pools[i].Replicas == nil {
+			flexible = append(flexible, i)
+			continue
+		}
+		share, err := intstr.GetScaledValueFromIntOrPercent(pools[i].Replicas, unitedSet.Spec.Replicas, true)
+		if err != nil {
+			return nil, fmt.Errorf("invalid replicas of pool %q: %w", pools[i].Name, err)
+		}
+		replicas[i] = min(share, remaining)
+		remaining -= replicas[i]
+	}
+
+	if len(flexible) == 0 {
+		if len(pools) > 0 {
+			replicas[len(pools)-1] += remaining
+		}
+		return replicas, nil
+	}
+	for n, i := range flexible {
+		replicas[i] = remaining / len(flexible)
+		if n < remaining%len(flexible) {
+			replicas[i]++
+		}
+	}
+	return replicas, nil
+}
+
+// poolMiniCloneSetName returns the name of the MiniCloneSet of a pool
+func poolMiniCloneSetName(unitedSet *appsexamplecomv1alpha1.MiniUnitedSet, pool *appsexamplecomv1alpha1.Pool) string {
+	return fmt.Sprintf("%s-%s", unitedSet.Name, pool.Name)
+}
+
+// setPoolMiniCloneSet sets the labels and the fields of a pool's MiniCloneSet
+// the pool controls: its replicas, a single spread subset selecting the
+// pool's nodes and the fields set in the template. Template changes since the
+// template was last applied are merged into the spec, so fields the template
+// leaves unset keep the values the API server defaulted and an unchanged
+// template does not update the MiniCloneSet.
+func setPoolMiniCloneSet(unitedSet *appsexamplecomv1alpha1.MiniUnitedSet, pool *appsexamplecomv1alpha1.Pool, replicas int, cloneSet *appsexamplecomv1alpha1.MiniCloneSet) error {
+	if cloneSet.Labels == nil {
+		cloneSet.Labels = map[string]string{}
+	}
+	maps.Copy(cloneSet.Labels, unitedSet.Spec.Template.Labels)
+	cloneSet.Labels[appsexamplecomv1alpha1.UnitedSetLabel] = unitedSet.Name
+	cloneSet.Labels[appsexamplecomv1alpha1.PoolLabel] = pool.Name
+
+	template, err := json.Marshal(unitedSet.Spec.Template.Spec)
+	if err != nil {
+		return err
+	}
+	if applied := cloneSet.Annotations[appsexamplecomv1alpha1.AppliedTemplateAnnotation]; applied != string(template) {
+		if applied == "" {
+			applied = "{}"
+		}
+		patch, err := jsonpatch.CreateMergePatch([]byte(applied), template)
+		if err != nil {
+			return fmt.Errorf("invalid applied template: %w", err)
+		}
+		current, err := json.Marshal(cloneSet.Spec)
+		if err != nil {
+			return err
+		}
+		merged, err := jsonpatch.MergePatch(current, patch)
+		if err != nil {
+			return err
+		}
+		spec := appsexamplecomv1alpha1.MiniCloneSetSpec{}
+		if err := json.Unmarshal(merged, &spec); err != nil {
+			return err
+		}
+		cloneSet.Spec = spec
+		if cloneSet.Annotations == nil {
+			cloneSet.Annotations = map[string]string{}
+		}
+		cloneSet.Annotations[appsexamplecomv1alpha1.AppliedTemplateAnnotation] = string(template)
+	}
+
+	cloneSet.Spec.Replicas = replicas
+	cloneSet.Spec.Spread = &appsexamplecomv1alpha1.SpreadPolicy{
+		Subsets: []appsexamplecomv1alpha1.SpreadSubset{{
+			Name:                     pool.Name,
+			RequiredNodeSelectorTerm: *pool.NodeSelectorTerm.DeepCopy(),
+			Tolerations:              pool.Tolerations,
+		}},
+	}
+	return nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *MiniUnitedSetReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&appsexamplecomv1alpha1.MiniUnitedSet{}).
+		Owns(&appsexamplecomv1alpha1.MiniCloneSet{}).
+		Named("miniunitedset").
+		Complete(r)
+}
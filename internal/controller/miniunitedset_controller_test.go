@@ -0,0 +1,94 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	appsexamplecomv1alpha1 "k8s.openkruise.com/v1/api/v1alpha1"
+)
+
+var _ = Describe("MiniUnitedSet Controller", func() {
+	newMiniUnitedSet := func(replicas int, shares ...*intstr.IntOrString) *appsexamplecomv1alpha1.MiniUnitedSet {
+		unitedSet := &appsexamplecomv1alpha1.MiniUnitedSet{}
+		unitedSet.Name = "web"
+		unitedSet.Spec.Replicas = replicas
+		unitedSet.Spec.Template.Spec.Image = "nginx:1.20"
+		for i, share := range shares {
+			unitedSet.Spec.Pools = append(unitedSet.Spec.Pools, appsexamplecomv1alpha1.Pool{
+				Name:     []string{"on-demand", "spot", "reserved"}[i],
+				Replicas: share,
+			})
+		}
+		return unitedSet
+	}
+	share := func(v intstr.IntOrString) *intstr.IntOrString { return &v }
+
+	It("should give fixed shares first and divide the rest evenly", func() {
+		replicas, err := poolReplicas(newMiniUnitedSet(7, share(intstr.FromString("30%")), nil, nil))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(replicas).To(Equal([]int{3, 2, 2}))
+	})
+
+	It("should give pods left over to the last pool when every share is fixed", func() {
+		replicas, err := poolReplicas(newMiniUnitedSet(6, share(intstr.FromInt32(2)), share(intstr.FromInt32(1))))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(replicas).To(Equal([]int{2, 4}))
+	})
+
+	It("should build each pool's MiniCloneSet from the template", func() {
+		unitedSet := newMiniUnitedSet(4, nil, nil)
+		unitedSet.Spec.Template.Labels = map[string]string{"team": "payments"}
+		cloneSet := &appsexamplecomv1alpha1.MiniCloneSet{}
+		Expect(setPoolMiniCloneSet(unitedSet, &unitedSet.Spec.Pools[1], 2, cloneSet)).To(Succeed())
+
+		Expect(poolMiniCloneSetName(unitedSet, &unitedSet.Spec.Pools[1])).To(Equal("web-spot"))
+		Expect(cloneSet.Labels).To(HaveKeyWithValue("team", "payments"))
+		Expect(cloneSet.Labels).To(HaveKeyWithValue(appsexamplecomv1alpha1.PoolLabel, "spot"))
+		Expect(cloneSet.Spec.Replicas).To(Equal(2))
+		Expect(cloneSet.Spec.Image).To(Equal("nginx:1.20"))
+		Expect(cloneSet.Spec.Spread.Subsets).To(HaveLen(1))
+		Expect(cloneSet.Spec.Spread.Subsets[0].Name).To(Equal("spot"))
+	})
+
+	It("should keep defaulted fields and only apply template changes", func() {
+		unitedSet := newMiniUnitedSet(4, nil, nil)
+		unitedSet.Spec.Template.Spec.Env = []corev1.EnvVar{{Name: "LOG_LEVEL", Value: "info"}}
+		cloneSet := &appsexamplecomv1alpha1.MiniCloneSet{}
+		Expect(setPoolMiniCloneSet(unitedSet, &unitedSet.Spec.Pools[0], 2, cloneSet)).To(Succeed())
+
+		By("leaving fields the API server defaulted alone")
+		cloneSet.Spec.UpdateStrategy = appsexamplecomv1alpha1.RollingUpdateStrategyType
+		cloneSet.Spec.PodManagementPolicy = appsexamplecomv1alpha1.ParallelPodManagement
+		defaulted := cloneSet.DeepCopy()
+		Expect(setPoolMiniCloneSet(unitedSet, &unitedSet.Spec.Pools[0], 2, cloneSet)).To(Succeed())
+		Expect(cloneSet).To(Equal(defaulted))
+
+		By("applying changed and removed template fields")
+		unitedSet.Spec.Template.Spec.Image = "nginx:1.21"
+		unitedSet.Spec.Template.Spec.Env = nil
+		Expect(setPoolMiniCloneSet(unitedSet, &unitedSet.Spec.Pools[0], 3, cloneSet)).To(Succeed())
+		Expect(cloneSet.Spec.Image).To(Equal("nginx:1.21"))
+		Expect(cloneSet.Spec.Env).To(BeEmpty())
+		Expect(cloneSet.Spec.Replicas).To(Equal(3))
+		Expect(cloneSet.Spec.UpdateStrategy).To(Equal(appsexamplecomv1alpha1.RollingUpdateStrategyType))
+		Expect(cloneSet.Spec.PodManagementPolicy).To(Equal(appsexamplecomv1alpha1.ParallelPodManagement))
+	})
+})
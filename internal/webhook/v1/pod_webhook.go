@@ -0,0 +1,264 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	appsexamplecomv1alpha1 "k8s.openkruise.com/v1/api/v1alpha1"
+)
+
+// nolint:unused
+// log is for logging in this package.
+var podlog = logf.Log.WithName("pod-resource")
+
+// SetupPodWebhookWithManager registers the pod unavailable budget webhook
+// with the manager. Pod deletes made as controllerUsername, the user the
+// controller runs as, are allowed.
+func SetupPodWebhookWithManager(mgr ctrl.Manager, controllerUsername string) error {
+	mgr.GetWebhookServer().Register("/validate--v1-pod", &webhook.Admission{Handler: &PodUnavailableBudgetValidator{
+		Client:             mgr.GetClient(),
+		Decoder:            admission.NewDecoder(mgr.GetScheme()),
+		ControllerUsername: controllerUsername,
+	}})
+	return nil
+}
+
+// The pods rule only matches pods carrying the MiniCloneSet label, outside
+// kube-system and the manager's namespace, see config/webhook. It fails closed
+// for those pods alone, so other workloads never depend on the manager.
+// Evictions cannot be matched by the labels of their pod, and fail open so
+// that node drains do not depend on the webhook; the PodDisruptionBudget of
+// spec.disruptionBudget still guards them.
+// +kubebuilder:webhook:path=/validate--v1-pod,mutating=false,failurePolicy=fail,sideEffects=None,groups="",resources=pods;pods/ephemeralcontainers,verbs=update;delete,versions=v1,name=vpod-v1.kb.io,admissionReviewVersions=v1
+// +kubebuilder:webhook:path=/validate--v1-pod,mutating=false,failurePolicy=ignore,sideEffects=None,groups="",resources=pods/eviction,verbs=create,versions=v1,name=vpodeviction-v1.kb.io,admissionReviewVersions=v1
+
+// PodUnavailableBudgetValidator denies deleting, evicting, updating the image
+// of, or restarting the containers of a ready MiniCloneSet pod in place when
+// that would leave the MiniCloneSet with fewer available pods than its
+// disruption budget allows. Unlike a PodDisruptionBudget, which only guards
+// evictions, it also covers plain deletes by other controllers and users, and
+// in-place disruptions by anyone.
+//
+// The controller's own in-place disruptions, container restarts and sidecar
+// image updates, are checked against the budget and retried once it allows
+// them. Its pod deletes are not: scale down, Recreate updates, hibernation and
+// PerNode pods of ineligible nodes delete pods on purpose and could never
+// complete within the budget, and rolling updates are bounded by the update
+// strategy. Pods of MiniCloneSets being deleted are never protected, so
+// garbage collection is not held up.
+//
+// The available pods are counted and the request is admitted without any
+// lock, so concurrent requests can each see the budget allow them and
+// together take down more pods than it allows.
+type PodUnavailableBudgetValidator struct {
+	Client  client.Client
+	Decoder admission.Decoder
+
+	// ControllerUsername is the user the controller runs as, whose pod
+	// deletes are always allowed. Unset, they are checked like any other.
+	ControllerUsername string
+}
+
+// Handle validates a pod delete, eviction or update
+func (v *PodUnavailableBudgetValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	var pod corev1.Pod
+	var action string
+	switch {
+	case req.SubResource == "eviction" && req.Operation == admissionv1.Create:
+		if err := v.Client.Get(ctx, types.NamespacedName{Namespace: req.Namespace, Name: req.Name}, &pod); err != nil {
+			if apierrors.IsNotFound(err) {
+				return admission.Allowed("")
+			}
+			return admission.Errored(http.StatusInternalServerError, err)
+		}
+		action = "evict"
+	case req.SubResource == "" && req.Operation == admissionv1.Delete:
+		if v.ControllerUsername != "" && req.UserInfo.Username == v.ControllerUsername {
+			return admission.Allowed("")
+		}
+		if err := v.Decoder.DecodeRaw(req.OldObject, &pod); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		action = "delete"
+	case req.SubResource == "" && req.Operation == admissionv1.Update:
+		var updated corev1.Pod
+		if err := v.Decoder.DecodeRaw(req.OldObject, &pod); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		if err := v.Decoder.DecodeRaw(req.Object, &updated); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		if !imagesChanged(&pod, &updated) {
+			return admission.Allowed("")
+		}
+		action = "update the image of"
+	case req.SubResource == "ephemeralcontainers" && req.Operation == admissionv1.Update:
+		var updated corev1.Pod
+		if err := v.Decoder.DecodeRaw(req.OldObject, &pod); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		if err := v.Decoder.DecodeRaw(req.Object, &updated); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		if !restartersAdded(&pod, &updated) {
+			return admission.Allowed("")
+		}
+		action = "restart containers of"
+	default:
+		return admission.Allowed("")
+	}
+
+	reason, err := v.unavailableBudgetExceeded(ctx, &pod)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if reason != "" {
+		podlog.Info("Denied pod disruption", "pod", pod.Name, "namespace", pod.Namespace, "action", action, "user", req.UserInfo.Username)
+		return admission.Denied(fmt.Sprintf("cannot %s pod %s: %s", action, pod.Name, reason))
+	}
+	return admission.Allowed("")
+}
+
+// unavailableBudgetExceeded returns why taking the pod down would exceed the
+// disruption budget of its MiniCloneSet, or an empty string if it would not
+func (v *PodUnavailableBudgetValidator) unavailableBudgetExceeded(ctx context.Context, pod *corev1.Pod) (string, error) {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil || owner.Kind != "MiniCloneSet" || owner.APIVersion != appsexamplecomv1alpha1.GroupVersion.String() {
+		return "", nil
+	}
+	// Pods that are not available do not count against the budget
+	if !isPodAvailable(pod) {
+		return "", nil
+	}
+
+	var cloneSet appsexamplecomv1alpha1.MiniCloneSet
+	if err := v.Client.Get(ctx, types.NamespacedName{Namespace: pod.Namespace, Name: owner.Name}, &cloneSet); err != nil {
+		return "", client.IgnoreNotFound(err)
+	}
+	if cloneSet.UID != owner.UID || cloneSet.DeletionTimestamp != nil || cloneSet.Spec.DisruptionBudget == nil {
+		return "", nil
+	}
+
+	desiredReplicas := cloneSet.Spec.Replicas
+	if cloneSet.Status.ObservedGeneration > 0 {
+		desiredReplicas = cloneSet.Status.DesiredReplicas
+	}
+	minAvailable, err := cloneSet.Spec.DisruptionBudget.MinAvailablePods(desiredReplicas)
+	if err != nil {
+		return "", err
+	}
+
+	var pods corev1.PodList
+	if err := v.Client.List(ctx, &pods, client.InNamespace(pod.Namespace), client.MatchingLabels{"app": cloneSet.Name}); err != nil {
+		return "", err
+	}
+	available := 0
+	for i := range pods.Items {
+		p := &pods.Items[i]
+		if metav1.IsControlledBy(p, &cloneSet) && isPodAvailable(p) {
+			available++
+		}
+	}
+	if available-1 < minAvailable {
+		return fmt.Sprintf("MiniCloneSet %s needs %d available pods and has %d", cloneSet.Name, minAvailable, available), nil
+	}
+	return "", nil
+}
+
+// imagesChanged checks if an update changes the image of any container
+func imagesChanged(old, updated *corev1.Pod) bool {
+	images := map[string]string{}
+	for _, container := range old.Spec.Containers {
+		images[container.Name] = container.Image
+	}
+	for _, container := range updated.Spec.Containers {
+		if image, ok := images[container.Name]; ok && image != container.Image {
+			return true
+		}
+	}
+	return false
+}
+
+// restartersAdded checks if an update of the ephemeral containers of a pod
+// adds a container restarting one of its containers in place
+func restartersAdded(old, updated *corev1.Pod) bool {
+	existing := map[string]bool{}
+	for _, container := range old.Spec.EphemeralContainers {
+		existing[container.Name] = true
+	}
+	for _, container := range updated.Spec.EphemeralContainers {
+		if !existing[container.Name] && strings.HasPrefix(container.Name, appsexamplecomv1alpha1.RestarterPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPodAvailable checks if a pod is ready and not being taken down, in place
+// or not. Pods whose containers restart or whose sidecar images were patched
+// may still report ready, but are about to become unavailable.
+func isPodAvailable(pod *corev1.Pod) bool {
+	if pod.DeletionTimestamp != nil || !isPodReady(pod) {
+		return false
+	}
+	if _, ok := pod.Annotations[appsexamplecomv1alpha1.RestartingToAnnotation]; ok {
+		return false
+	}
+	if _, ok := pod.Annotations[appsexamplecomv1alpha1.SidecarUpdatingAnnotation]; ok {
+		return false
+	}
+	for _, container := range pod.Spec.EphemeralContainers {
+		if !strings.HasPrefix(container.Name, appsexamplecomv1alpha1.RestarterPrefix) {
+			continue
+		}
+		terminated := false
+		for _, status := range pod.Status.EphemeralContainerStatuses {
+			if status.Name == container.Name && status.State.Terminated != nil {
+				terminated = true
+			}
+		}
+		if !terminated {
+			return false
+		}
+	}
+	return true
+}
+
+// isPodReady checks if a pod is ready
+func isPodReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady && condition.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
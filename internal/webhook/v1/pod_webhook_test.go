@@ -0,0 +1,175 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	appsexamplecomv1alpha1 "k8s.openkruise.com/v1/api/v1alpha1"
+)
+
+var _ = Describe("Pod Webhook", func() {
+	var validator *PodUnavailableBudgetValidator
+	var pods []*corev1.Pod
+
+	BeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(appsexamplecomv1alpha1.AddToScheme(scheme)).To(Succeed())
+
+		maxUnavailable := intstr.FromInt32(1)
+		cloneSet := &appsexamplecomv1alpha1.MiniCloneSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", UID: "web"},
+			Spec: appsexamplecomv1alpha1.MiniCloneSetSpec{
+				Replicas:         3,
+				DisruptionBudget: &appsexamplecomv1alpha1.DisruptionBudget{MaxUnavailable: &maxUnavailable},
+			},
+		}
+		objs := []client.Object{cloneSet}
+		pods = nil
+		for i := range 3 {
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            fmt.Sprintf("web-%d", i),
+					Namespace:       "default",
+					Labels:          map[string]string{"app": "web"},
+					OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(cloneSet, appsexamplecomv1alpha1.GroupVersion.WithKind("MiniCloneSet"))},
+				},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "main", Image: "nginx:1.20"}}},
+				Status: corev1.PodStatus{Conditions: []corev1.PodCondition{
+					{Type: corev1.PodReady, Status: corev1.ConditionTrue},
+				}},
+			}
+			pods = append(pods, pod)
+			objs = append(objs, pod)
+		}
+		validator = &PodUnavailableBudgetValidator{
+			Client:             fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build(),
+			Decoder:            admission.NewDecoder(scheme),
+			ControllerUsername: "system:serviceaccount:system:controller-manager",
+		}
+	})
+
+	raw := func(obj runtime.Object) runtime.RawExtension {
+		data, err := json.Marshal(obj)
+		Expect(err).NotTo(HaveOccurred())
+		return runtime.RawExtension{Raw: data}
+	}
+	deleteRequest := func(pod *corev1.Pod, username string) admission.Request {
+		return admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+			Operation: admissionv1.Delete,
+			Namespace: pod.Namespace,
+			Name:      pod.Name,
+			OldObject: raw(pod),
+			UserInfo:  authenticationv1.UserInfo{Username: username},
+		}}
+	}
+
+	It("should allow a delete that keeps the budget", func() {
+		Expect(validator.Handle(ctx, deleteRequest(pods[0], "alice")).Allowed).To(BeTrue())
+	})
+
+	It("should deny a delete or eviction that exceeds the budget", func() {
+		pods[1].Status.Conditions = nil
+		Expect(validator.Client.Status().Update(ctx, pods[1])).To(Succeed())
+
+		response := validator.Handle(ctx, deleteRequest(pods[0], "alice"))
+		Expect(response.Allowed).To(BeFalse())
+		Expect(response.Result.Message).To(ContainSubstring("needs 2 available pods and has 2"))
+
+		eviction := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+			Operation:   admissionv1.Create,
+			SubResource: "eviction",
+			Namespace:   "default",
+			Name:        "web-0",
+		}}
+		Expect(validator.Handle(ctx, eviction).Allowed).To(BeFalse())
+
+		// Unavailable pods and deletes of the controller itself are not held back
+		Expect(validator.Handle(ctx, deleteRequest(pods[1], "alice")).Allowed).To(BeTrue())
+		Expect(validator.Handle(ctx, deleteRequest(pods[0], validator.ControllerUsername)).Allowed).To(BeTrue())
+	})
+
+	It("should count pods disrupted in place as unavailable", func() {
+		restarting := pods[1].DeepCopy()
+		restarting.Annotations = map[string]string{appsexamplecomv1alpha1.SidecarUpdatingAnnotation: "logging"}
+		Expect(validator.Client.Update(ctx, restarting)).To(Succeed())
+
+		Expect(validator.Handle(ctx, deleteRequest(pods[0], "alice")).Allowed).To(BeFalse())
+		// The pod being disrupted already is not held back
+		Expect(validator.Handle(ctx, deleteRequest(restarting, "alice")).Allowed).To(BeTrue())
+	})
+
+	It("should check container restarts of the controller against the budget", func() {
+		pods[1].Status.Conditions = nil
+		Expect(validator.Client.Status().Update(ctx, pods[1])).To(Succeed())
+		restart := func(name string) admission.Request {
+			updated := pods[0].DeepCopy()
+			updated.Spec.EphemeralContainers = []corev1.EphemeralContainer{{
+				EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: name, Image: "busybox:1.36"},
+			}}
+			return admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+				Operation:   admissionv1.Update,
+				SubResource: "ephemeralcontainers",
+				Namespace:   "default",
+				Name:        "web-0",
+				Object:      raw(updated),
+				OldObject:   raw(pods[0]),
+				UserInfo:    authenticationv1.UserInfo{Username: validator.ControllerUsername},
+			}}
+		}
+
+		Expect(validator.Handle(ctx, restart("debugger")).Allowed).To(BeTrue())
+		response := validator.Handle(ctx, restart(appsexamplecomv1alpha1.RestarterPrefix+"main-new"))
+		Expect(response.Allowed).To(BeFalse())
+		Expect(response.Result.Message).To(ContainSubstring("cannot restart containers of pod web-0"))
+	})
+
+	It("should only check updates that change an image in place", func() {
+		pods[1].Status.Conditions = nil
+		Expect(validator.Client.Status().Update(ctx, pods[1])).To(Succeed())
+		update := func(image string) admission.Request {
+			updated := pods[0].DeepCopy()
+			updated.Labels["tier"] = "web"
+			updated.Spec.Containers[0].Image = image
+			return admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+				Operation: admissionv1.Update,
+				Namespace: "default",
+				Name:      "web-0",
+				Object:    raw(updated),
+				OldObject: raw(pods[0]),
+			}}
+		}
+
+		Expect(validator.Handle(ctx, update("nginx:1.20")).Allowed).To(BeTrue())
+		Expect(validator.Handle(ctx, update("nginx:1.21")).Allowed).To(BeFalse())
+	})
+})
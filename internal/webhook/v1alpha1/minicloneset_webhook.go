@@ -0,0 +1,115 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	appsexamplecomv1alpha1 "k8s.openkruise.com/v1/api/v1alpha1"
+)
+
+// nolint:unused
+// log is for logging in this package.
+var miniclonesetlog = logf.Log.WithName("minicloneset-resource")
+
+// SetupMiniCloneSetWebhookWithManager registers the webhook for MiniCloneSet in the manager.
+func SetupMiniCloneSetWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&appsexamplecomv1alpha1.MiniCloneSet{}).
+		WithValidator(&MiniCloneSetCustomValidator{Client: mgr.GetClient()}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/validate-apps-example-com-my-domain-v1alpha1-minicloneset,mutating=false,failurePolicy=fail,sideEffects=None,groups=apps.example.com.my.domain,resources=miniclonesets,verbs=delete,versions=v1alpha1,name=vminicloneset-v1alpha1.kb.io,admissionReviewVersions=v1
+
+// MiniCloneSetCustomValidator enforces the deletion protection label of a
+// MiniCloneSet. Deleting a MiniCloneSet deletes all of its pods through
+// garbage collection, so a protected one is only deleted once the label is
+// removed or, with Cascading, once it has no ready pods left.
+type MiniCloneSetCustomValidator struct {
+	Client client.Client
+}
+
+var _ webhook.CustomValidator = &MiniCloneSetCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type MiniCloneSet.
+func (v *MiniCloneSetCustomValidator) ValidateCreate(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type MiniCloneSet.
+func (v *MiniCloneSetCustomValidator) ValidateUpdate(_ context.Context, _, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type MiniCloneSet.
+func (v *MiniCloneSetCustomValidator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	minicloneset, ok := obj.(*appsexamplecomv1alpha1.MiniCloneSet)
+	if !ok {
+		return nil, fmt.Errorf("expected a MiniCloneSet object but got %T", obj)
+	}
+	override := fmt.Sprintf("remove the label to delete it: kubectl label minicloneset %s -n %s %s-",
+		minicloneset.Name, minicloneset.Namespace, appsexamplecomv1alpha1.DeletionProtectionLabel)
+
+	switch minicloneset.Labels[appsexamplecomv1alpha1.DeletionProtectionLabel] {
+	case appsexamplecomv1alpha1.DeletionProtectionAlways:
+		miniclonesetlog.Info("Denied deletion of protected MiniCloneSet", "name", minicloneset.Name, "namespace", minicloneset.Namespace)
+		return nil, fmt.Errorf("MiniCloneSet %s is protected from deletion by %s=%s; %s",
+			minicloneset.Name, appsexamplecomv1alpha1.DeletionProtectionLabel, appsexamplecomv1alpha1.DeletionProtectionAlways, override)
+	case appsexamplecomv1alpha1.DeletionProtectionCascading:
+		readyPods, err := v.readyPods(ctx, minicloneset)
+		if err != nil {
+			return nil, err
+		}
+		if readyPods > 0 {
+			miniclonesetlog.Info("Denied deletion of protected MiniCloneSet", "name", minicloneset.Name, "namespace", minicloneset.Namespace, "readyPods", readyPods)
+			return nil, fmt.Errorf("MiniCloneSet %s still has %d ready pods and is protected from deletion by %s=%s; scale it to 0 replicas first or %s",
+				minicloneset.Name, readyPods, appsexamplecomv1alpha1.DeletionProtectionLabel, appsexamplecomv1alpha1.DeletionProtectionCascading, override)
+		}
+	}
+	return nil, nil
+}
+
+// readyPods counts the ready pods of the MiniCloneSet
+func (v *MiniCloneSetCustomValidator) readyPods(ctx context.Context, minicloneset *appsexamplecomv1alpha1.MiniCloneSet) (int, error) {
+	var pods corev1.PodList
+	if err := v.Client.List(ctx, &pods, client.InNamespace(minicloneset.Namespace), client.MatchingLabels{"app": minicloneset.Name}); err != nil {
+		return 0, err
+	}
+	ready := 0
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if !metav1.IsControlledBy(pod, minicloneset) || pod.DeletionTimestamp != nil {
+			continue
+		}
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == corev1.PodReady && condition.Status == corev1.ConditionTrue {
+				ready++
+			}
+		}
+	}
+	return ready, nil
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	appsexamplecomv1alpha1 "k8s.openkruise.com/v1/api/v1alpha1"
+)
+
+var _ = Describe("MiniCloneSet Webhook", func() {
+	var (
+		obj       *appsexamplecomv1alpha1.MiniCloneSet
+		pod       *corev1.Pod
+		validator MiniCloneSetCustomValidator
+	)
+
+	BeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(appsexamplecomv1alpha1.AddToScheme(scheme)).To(Succeed())
+		obj = &appsexamplecomv1alpha1.MiniCloneSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", UID: "web"},
+		}
+		pod = &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "web-0",
+				Namespace:       "default",
+				Labels:          map[string]string{"app": "web"},
+				OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(obj, appsexamplecomv1alpha1.GroupVersion.WithKind("MiniCloneSet"))},
+			},
+			Status: corev1.PodStatus{Conditions: []corev1.PodCondition{
+				{Type: corev1.PodReady, Status: corev1.ConditionTrue},
+			}},
+		}
+		validator = MiniCloneSetCustomValidator{
+			Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(pod).WithStatusSubresource(pod).Build(),
+		}
+	})
+
+	Context("When deleting MiniCloneSet under Validating Webhook", func() {
+		It("Should allow deleting an unprotected MiniCloneSet", func() {
+			Expect(validator.ValidateDelete(ctx, obj)).Error().NotTo(HaveOccurred())
+		})
+
+		It("Should deny deleting a MiniCloneSet protected Always and tell how to override", func() {
+			obj.Labels = map[string]string{appsexamplecomv1alpha1.DeletionProtectionLabel: appsexamplecomv1alpha1.DeletionProtectionAlways}
+			_, err := validator.ValidateDelete(ctx, obj)
+			Expect(err).To(MatchError(ContainSubstring("kubectl label minicloneset web -n default policy.deletion-protection-")))
+		})
+
+		It("Should deny deleting a Cascading protected MiniCloneSet only while it has ready pods", func() {
+			obj.Labels = map[string]string{appsexamplecomv1alpha1.DeletionProtectionLabel: appsexamplecomv1alpha1.DeletionProtectionCascading}
+			_, err := validator.ValidateDelete(ctx, obj)
+			Expect(err).To(MatchError(ContainSubstring("still has 1 ready pods")))
+
+			pod.Status.Conditions = nil
+			Expect(validator.Client.Status().Update(ctx, pod)).To(Succeed())
+			Expect(validator.ValidateDelete(ctx, obj)).Error().NotTo(HaveOccurred())
+		})
+	})
+})